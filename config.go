@@ -0,0 +1,107 @@
+package gloop
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules"
+	"github.com/gloopai/gloop/servicehub"
+)
+
+// 将容器配置文件中的组件配置段解析到实现了 modules.Configurable 的组件，
+// 配置段不存在时保留组件原有配置
+func (c *Container) doConfigureComponents() error {
+	c.reloadMu.Lock()
+	tree := c.configTree
+	c.reloadMu.Unlock()
+	if tree == nil {
+		return nil
+	}
+	for _, comp := range c.components {
+		configurable, ok := modules.Unwrap(comp).(modules.Configurable)
+		if !ok {
+			continue
+		}
+		if err := configureComponent(tree, configurable); err != nil {
+			return fmt.Errorf("configure component '%s' failed: %w", comp.Name(), err)
+		}
+	}
+	return nil
+}
+
+// configureComponent 解析并校验单个组件的配置段，错误中带有配置段路径
+func configureComponent(tree lib.ConfigTree, configurable modules.Configurable) error {
+	return decodeSection(tree, configurable.ConfigSection(), configurable.ConfigOptions())
+}
+
+// decodeSection 将配置段解析到 options 并校验，配置段不存在时不做修改
+func decodeSection(tree lib.ConfigTree, section string, options any) error {
+	sectionTree := tree.Section(section)
+	if sectionTree == nil {
+		return nil
+	}
+	if err := sectionTree.DecodeStrict(options); err != nil {
+		if unknown, ok := err.(*lib.ErrUnknownConfigKeys); ok {
+			for i, key := range unknown.Keys {
+				unknown.Keys[i] = section + "." + key
+			}
+			return unknown
+		}
+		return fmt.Errorf("config [%s]: %w", section, err)
+	}
+	if err := lib.Verification.Validator(options); err != nil {
+		return fmt.Errorf("config [%s]: %w", section, err)
+	}
+	return nil
+}
+
+// applyServicePolicies 将配置中的服务弹性策略合并到 hub。配置在组件启动后应用，其中的各项覆盖
+// 注册时通过 WithPolicy 设置的对应项，未配置的项与降级函数保留。old 为上次应用的配置，与其相同的策略跳过，
+// old 中存在而 policies 中已删除的策略恢复为注册时的策略，注册时没有策略则移除
+func (c *Container) applyServicePolicies(old, policies map[string]servicehub.Policy) {
+	hub := c.hub()
+	if c.registeredPolicies == nil {
+		c.registeredPolicies = make(map[string]*servicehub.Policy)
+	}
+	for name, policy := range policies {
+		if previous, exists := old[name]; exists && reflect.DeepEqual(previous, policy) {
+			continue
+		}
+		registered, seen := c.registeredPolicies[name]
+		if !seen {
+			// 首次应用前记录注册时的策略，之后的合并都以它为基础
+			if current, ok := hub.Policy(name); ok {
+				registered = &current
+			}
+			c.registeredPolicies[name] = registered
+		}
+		var merged servicehub.Policy
+		if registered != nil {
+			merged = *registered
+		}
+		if policy.Retry != nil {
+			merged.Retry = policy.Retry
+		}
+		if policy.Breaker != nil {
+			merged.Breaker = policy.Breaker
+		}
+		if policy.Bulkhead != nil {
+			merged.Bulkhead = policy.Bulkhead
+		}
+		hub.SetPolicy(name, merged)
+		lib.Log.Infof("[Container] applied service policy for '%s'", name)
+	}
+	for name := range old {
+		if _, exists := policies[name]; exists {
+			continue
+		}
+		if registered := c.registeredPolicies[name]; registered != nil {
+			hub.SetPolicy(name, *registered)
+		} else {
+			hub.RemovePolicy(name)
+		}
+		delete(c.registeredPolicies, name)
+		lib.Log.Infof("[Container] removed configured service policy for '%s'", name)
+	}
+}
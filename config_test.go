@@ -0,0 +1,162 @@
+package gloop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules/db"
+	"github.com/gloopai/gloop/modules/site"
+	"github.com/gloopai/gloop/servicehub"
+)
+
+func loadTestTree(t *testing.T, content string) lib.ConfigTree {
+	path := filepath.Join(t.TempDir(), "container.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := lib.Conf.LoadLayered(&ContainerConfig{}, lib.LayerOptions{
+		Files:   []string{path},
+		Args:    []string{},
+		Environ: []string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestConfigureComponent_Sections(t *testing.T) {
+	tree := loadTestTree(t, `
+LogLevel = 4
+
+[site.admin]
+port = 9001
+cross_origin = true
+static_file_cache_ttl = "1m"
+
+[db.main]
+db_path = "data/main.db"
+`)
+	admin := site.NewSite(site.SiteOptions{Name: "admin", Port: 8080})
+	other := site.NewSite(site.SiteOptions{Name: "public", Port: 8081})
+	main := db.NewDb(db.DbOptions{Name: "main"})
+	for _, comp := range []interface {
+		ConfigSection() string
+		ConfigOptions() any
+	}{admin, other, main} {
+		if err := configureComponent(tree, comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if admin.Config.Port != 9001 || !admin.Config.CrossOrigin || admin.Config.StaticFileCacheTTL != time.Minute {
+		t.Errorf("site section not applied: %+v", admin.Config)
+	}
+	if other.Config.Port != 8081 {
+		t.Errorf("site without section should keep its options: %+v", other.Config)
+	}
+	if main.Config.DbPath != "data/main.db" {
+		t.Errorf("db section not applied: %+v", main.Config)
+	}
+}
+
+func TestConfigureComponent_Errors(t *testing.T) {
+	tree := loadTestTree(t, `
+[site.admin]
+prot = 9001
+
+[site.bad]
+port = 70000
+
+[db.main]
+name = "main"
+`)
+	err := configureComponent(tree, site.NewSite(site.SiteOptions{Name: "admin"}))
+	if err == nil || !strings.Contains(err.Error(), "site.admin.prot") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+	err = configureComponent(tree, site.NewSite(site.SiteOptions{Name: "bad"}))
+	if err == nil || !strings.Contains(err.Error(), "[site.bad]") || !strings.Contains(err.Error(), "Port") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	err = configureComponent(tree, db.NewDb(db.DbOptions{Name: "main"}))
+	if err == nil || !strings.Contains(err.Error(), "db_path") {
+		t.Errorf("expected required db_path error, got %v", err)
+	}
+}
+
+func TestApplyServicePolicies_MergesWithRegistration(t *testing.T) {
+	tree := loadTestTree(t, `
+[service_policies."user.get".retry]
+max_attempts = 5
+initial_backoff = "10ms"
+
+[service_policies."user.get".breaker]
+failure_threshold = 3
+`)
+	config := &ContainerConfig{}
+	if err := tree.Decode(config); err != nil {
+		t.Fatal(err)
+	}
+
+	hub := servicehub.NewServiceHub()
+	fallback := servicehub.Fallback(func(ctx context.Context, req int, err error) (int, error) { return 0, nil })
+	err := servicehub.Register(hub, "user.get", func(req int) (int, error) { return req, nil }, servicehub.WithPolicy(servicehub.Policy{
+		Retry:    &servicehub.RetryPolicy{MaxAttempts: 2},
+		Bulkhead: &servicehub.BulkheadPolicy{MaxConcurrent: 4},
+		Fallback: fallback,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{Hub: hub}
+	c.applyServicePolicies(nil, config.ServicePolicies)
+
+	policy, ok := hub.Policy("user.get")
+	if !ok {
+		t.Fatal("policy not applied")
+	}
+	if policy.Retry.MaxAttempts != 5 || policy.Retry.InitialBackoff != 10*time.Millisecond {
+		t.Errorf("retry not overridden: %+v", policy.Retry)
+	}
+	if policy.Breaker == nil || policy.Breaker.FailureThreshold != 3 {
+		t.Errorf("breaker not applied: %+v", policy.Breaker)
+	}
+	if policy.Bulkhead == nil || policy.Bulkhead.MaxConcurrent != 4 || policy.Fallback == nil {
+		t.Errorf("registration settings should be kept: %+v", policy)
+	}
+}
+
+func TestApplyServicePolicies_RestoresRemoved(t *testing.T) {
+	hub := servicehub.NewServiceHub()
+	registered := servicehub.Policy{Retry: &servicehub.RetryPolicy{MaxAttempts: 2}}
+	err := servicehub.Register(hub, "user.get", func(req int) (int, error) { return req, nil }, servicehub.WithPolicy(registered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{Hub: hub}
+	first := map[string]servicehub.Policy{
+		"user.get":   {Retry: &servicehub.RetryPolicy{MaxAttempts: 5}},
+		"order.list": {Breaker: &servicehub.BreakerPolicy{FailureThreshold: 3}},
+	}
+	c.applyServicePolicies(nil, first)
+
+	// 修改后的配置以注册时的策略为基础合并，而不是上次合并的结果
+	second := map[string]servicehub.Policy{"user.get": {Breaker: &servicehub.BreakerPolicy{FailureThreshold: 4}}}
+	c.applyServicePolicies(first, second)
+	if policy, _ := hub.Policy("user.get"); policy.Retry.MaxAttempts != 2 || policy.Breaker == nil {
+		t.Errorf("expected registration retry with configured breaker, got %+v", policy)
+	}
+	if _, ok := hub.Policy("order.list"); ok {
+		t.Error("policy deleted from config should be removed")
+	}
+
+	c.applyServicePolicies(second, nil)
+	if policy, ok := hub.Policy("user.get"); !ok || policy.Breaker != nil || policy.Retry.MaxAttempts != 2 {
+		t.Errorf("expected registration policy to be restored, got %+v", policy)
+	}
+}
@@ -1,38 +1,82 @@
 package gloop
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/health"
 	"github.com/gloopai/gloop/lib"
 	"github.com/gloopai/gloop/modules"
+	"github.com/gloopai/gloop/servicehub"
 )
 
 type Container struct {
-	Config     *ContainerConfig
-	components []modules.Component
+	Config     *ContainerConfig // 热加载时整体替换，运行期间通过 config() 读取
+	components []modules.ComponentV2
 	Node       *modules.Node
+	Events     *events.EventBus       // 发布组件状态变化，停机时等待其处理中的事件处理器
+	Health     *health.Registry       // 容器与组件的健康检查
+	Hub        *servicehub.ServiceHub // 容器的服务中心，默认为 ServiceHub 单例
+
+	initialized     int            // 已完成初始化的组件数量，用于回滚与停止
+	configTree      lib.ConfigTree // 合并后的完整配置
+	restartPolicies map[string]modules.RestartPolicy
+	// registeredPolicies 首次应用配置前服务注册时的弹性策略，为空表示注册时没有策略
+	registeredPolicies map[string]*servicehub.Policy
+	supervisor         *supervisor
+	ready              atomic.Bool  // 启动完成且未开始停机
+	admin              *http.Server // 管理端口服务器
+	layers             lib.LayerOptions
+	reloadMu           sync.Mutex // 保护 Config 与 configTree 的读取与热加载时的替换
 }
 
 type ContainerConfig struct {
-	LogLevel lib.LogLevel
-	Debug    bool
+	LogLevel        lib.LogLevel  `default:"4"`
+	Debug           bool          `default:"false"`
+	ShutdownTimeout time.Duration `default:"30s"` // 优雅停机的最长时间，如 "30s"
+
+	AdminAddr          string        // 管理端口地址，如 ":9090"，提供 /healthz、/readyz、/livez 与服务目录 /services，为空时不启动
+	HealthCheckTimeout time.Duration // 单项健康检查超时时间，默认 3 秒
+	HealthCacheTTL     time.Duration // 健康检查结果缓存时间，默认 2 秒
+	ReloadInterval     time.Duration `default:"5s"` // 配置文件变化检查间隔，0 表示不热加载
+
+	// ServicePolicies 按服务名称配置调用的弹性策略，在组件启动后应用，覆盖注册时通过 WithPolicy 设置的对应项，
+	// 热加载时删除的策略恢复为注册时的策略，如
+	// [service_policies."user.get".breaker]
+	ServicePolicies map[string]servicehub.Policy
 }
 
-// NewContainer 创建一个容器
+// NewContainer 创建一个容器，配置按默认值、container.toml（或 --config 指定的文件）、
+// GLOOP_ 前缀的环境变量、--config. 前缀的命令行参数的顺序分层加载
 func NewContainer() *Container {
-	config, err := loadWebhookOptions()
+	layers := lib.LayerOptions{Files: []string{"container.toml"}}
+	config, tree, err := loadContainerOptions(layers)
 	if err != nil {
-		log.Fatalf("[NewWebhook] Failed to load webhook configuration: %v", err)
+		log.Fatalf("[NewContainer] Failed to load container configuration: %v", err)
 	}
 	lib.Log.SetLogLevel(config.LogLevel)
 	lib.Log.SetDebugEnabled(config.Debug)
 
 	c := &Container{
-		Config: config,
+		Config:     config,
+		configTree: tree,
+		layers:     layers,
+		Events:     events.NewEventBus(),
+		Hub:        servicehub.GetHubInstance(),
+		Health: health.NewRegistry(health.Options{
+			Timeout:  config.HealthCheckTimeout,
+			CacheTTL: config.HealthCacheTTL,
+		}),
 	}
 
 	// node, err := modules.NewNode()
@@ -45,82 +89,247 @@ func NewContainer() *Container {
 	return c
 }
 
-func loadWebhookOptions() (*ContainerConfig, error) {
-	var options *ContainerConfig
-	err := lib.Conf.LoadTOML("container.toml", &options)
+func loadContainerOptions(layers lib.LayerOptions) (*ContainerConfig, lib.ConfigTree, error) {
+	options := &ContainerConfig{}
+	tree, err := lib.Conf.LoadLayered(options, layers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load webhook configuration: %v", err)
+		return nil, nil, fmt.Errorf("failed to load container configuration: %v", err)
 	}
 
-	return options, nil
+	return options, tree, nil
 }
 
-// Add 添加组件
+// Add 添加 v1 组件，添加顺序无关紧要：组件实现 modules.Dependent 声明依赖后，
+// 容器会按依赖关系排序初始化、注册服务和启动，并按逆序停止
 func (c *Container) Add(components ...modules.Component) {
+	for _, comp := range components {
+		c.components = append(c.components, modules.Adapt(comp))
+	}
+}
+
+// AddV2 添加支持 context 与错误返回的组件
+func (c *Container) AddV2(components ...modules.ComponentV2) {
 	c.components = append(c.components, components...)
 }
 
-// Serve 启动容器
+// UseEventBus 设置容器使用的事件总线
+func (c *Container) UseEventBus(eb *events.EventBus) {
+	c.Events = eb
+}
+
+// SetRestartPolicy 设置指定名称组件的重启策略，覆盖组件通过 modules.Supervised 声明的策略
+func (c *Container) SetRestartPolicy(name string, policy modules.RestartPolicy) {
+	if c.restartPolicies == nil {
+		c.restartPolicies = make(map[string]modules.RestartPolicy)
+	}
+	c.restartPolicies[name] = policy
+}
+
+// ComponentStates 返回所有组件的状态快照，容器运行前返回空
+func (c *Container) ComponentStates() []modules.ComponentStatus {
+	if c.supervisor == nil {
+		return nil
+	}
+	return c.supervisor.statuses()
+}
+
+// Serve 启动容器并阻塞，启动失败时回滚已启动的组件并以非零状态退出
 func (c *Container) Serve() {
+	if err := c.Run(context.Background()); err != nil {
+		lib.Log.Errorf("[Container] %v", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Run 启动容器并阻塞，直到收到退出信号或 ctx 结束后优雅停机。
+// 任一生命周期阶段失败时，已初始化的组件会按逆序停止，并返回该错误。
+// 停机过程中再次收到退出信号会立即强制退出。
+func (c *Container) Run(ctx context.Context) error {
 	c.doPrintFrameworkInfo()
 	// 初始化节点
 	// c.Node.Init()
 	// c.Node.Start()
 
-	c.doInitComponents()
-	c.doRegComponentsService()
-	c.doStartComponents()
+	if err := c.doSortComponents(); err != nil {
+		return err
+	}
+	if err := c.doConfigureComponents(); err != nil {
+		return err
+	}
+	c.supervisor = newSupervisor(c.Events, c.components, c.restartPolicies)
+	if c.Health == nil {
+		c.Health = health.NewRegistry(health.Options{})
+	}
+	c.doRegisterHealthChecks()
+	if err := c.doStartAdmin(); err != nil {
+		return err
+	}
+
+	ctx = modules.WithComponentContext(ctx, &modules.ComponentContext{
+		Node:   c.Node,
+		Events: c.Events,
+		Hub:    c.hub(),
+	})
+	runCtx := c.supervisor.start(ctx)
+	if err := c.doStartup(runCtx); err != nil {
+		c.doRollback()
+		return err
+	}
+	if config := c.config(); config != nil {
+		c.applyServicePolicies(nil, config.ServicePolicies)
+	}
+	c.ready.Store(true)
+	c.doWatchConfig(runCtx)
 
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalChan)
 
-	go func() {
-		<-signalChan
-		c.doDestroyComponents()
-		os.Exit(0)
-	}()
+	select {
+	case <-signalChan:
+		lib.Log.Info("[Container] received shutdown signal")
+		go func() {
+			<-signalChan
+			lib.Log.Warn("[Container] received second signal, forcing exit")
+			lib.Log.Flush()
+			os.Exit(1)
+		}()
+	case <-ctx.Done():
+	}
+	return c.doShutdown()
+}
 
-	// Keep the program running
-	select {}
+// config 返回当前的容器配置，与热加载互斥
+func (c *Container) config() *ContainerConfig {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	return c.Config
 }
 
-// 初始化所有组件
-func (c *Container) doInitComponents() {
-	for _, comp := range c.components {
-		comp.SetContext(&modules.ComponentContext{
-			Node: c.Node,
-		})
-		comp.Init()
+// hub 返回容器的服务中心，未设置时为 ServiceHub 单例
+func (c *Container) hub() *servicehub.ServiceHub {
+	if c.Hub == nil {
+		return servicehub.GetHubInstance()
 	}
+	return c.Hub
+}
+
+// 启动失败时在停机时限内回滚已初始化的组件
+func (c *Container) doRollback() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout())
+	defer cancel()
+	if err := c.supervisor.shutdown(ctx); err != nil {
+		lib.Log.Errorf("[Container] %v", err)
+	}
+	c.doStopComponents(ctx)
+	c.doStopAdmin(ctx)
+}
+
+// 按依赖关系排序组件
+func (c *Container) doSortComponents() error {
+	sorted, err := modules.SortComponents(c.components)
+	if err != nil {
+		return err
+	}
+	c.components = sorted
+	return nil
+}
+
+// 依次初始化、注册服务、启动所有组件
+func (c *Container) doStartup(ctx context.Context) error {
+	if err := c.doInitComponents(ctx); err != nil {
+		return err
+	}
+	if err := c.doRegComponentsService(ctx); err != nil {
+		return err
+	}
+	return c.doStartComponents(ctx)
 }
 
 // 初始化所有组件
-func (c *Container) doRegComponentsService() {
-	for _, comp := range c.components {
-		comp.RegisterService()
+func (c *Container) doInitComponents(ctx context.Context) error {
+	for i, comp := range c.components {
+		entry := c.supervisor.entries[i]
+		c.supervisor.transition(entry, modules.StateInitializing, nil)
+		if err := comp.Init(ctx); err != nil {
+			err = fmt.Errorf("init component '%s' failed: %w", comp.Name(), err)
+			c.supervisor.transition(entry, modules.StateFailed, err)
+			return err
+		}
+		c.initialized++
 	}
+	return nil
 }
 
-// 启动所有组件
-func (c *Container) doStartComponents() {
-	for _, comp := range c.components {
-		go comp.Start()
+// 注册所有组件的服务，未使用 WithComponent 的服务记录为该组件注册；失败的组件与启动失败一样标记为失败状态
+func (c *Container) doRegComponentsService(ctx context.Context) error {
+	for i, comp := range c.components {
+		registrar, ok := comp.(modules.ServiceRegistrar)
+		if !ok {
+			continue
+		}
+		end := c.hub().RegisteringFor(comp.Name())
+		err := registrar.RegisterService(ctx)
+		end()
+		if err != nil {
+			err = fmt.Errorf("register service of component '%s' failed: %w", comp.Name(), err)
+			c.supervisor.transition(c.supervisor.entries[i], modules.StateFailed, err)
+			return err
+		}
 	}
+	return nil
 }
 
-// 销毁所有组件
-func (c *Container) doDestroyComponents() {
-	for _, comp := range c.components {
-		comp.Destroy()
+// 启动所有组件，并监管实现了 modules.Runner 的组件主循环
+func (c *Container) doStartComponents(ctx context.Context) error {
+	for i, comp := range c.components {
+		entry := c.supervisor.entries[i]
+		if err := comp.Start(ctx); err != nil {
+			err = fmt.Errorf("start component '%s' failed: %w", comp.Name(), err)
+			c.supervisor.transition(entry, modules.StateFailed, err)
+			return err
+		}
+		c.supervisor.transition(entry, modules.StateRunning, nil)
+		c.supervisor.supervise(ctx, entry)
 	}
+	return nil
+}
+
+// 按启动的逆序停止已初始化的组件
+func (c *Container) doStopComponents(ctx context.Context) error {
+	var errs []error
+	for i := c.initialized - 1; i >= 0; i-- {
+		comp := c.components[i]
+		if err := comp.Stop(ctx); err != nil {
+			lib.Log.Errorf("[Container] stop component '%s' failed: %v", comp.Name(), err)
+			errs = append(errs, fmt.Errorf("stop component '%s' failed: %w", comp.Name(), err))
+		}
+		if c.supervisor != nil {
+			c.supervisor.transition(c.supervisor.entries[i], modules.StateStopped, nil)
+		}
+	}
+	c.initialized = 0
+	return errors.Join(errs...)
 }
 
 // 打印框架信息
 func (c *Container) doPrintFrameworkInfo() {
 	modules.PrintFrameworkInfo()
 
+	config := c.config()
 	infos := make([]string, 0, 7)
-	infos = append(infos, fmt.Sprintf("Debug: %v", c.Config.Debug))
-	infos = append(infos, fmt.Sprintf("LogLevel: %v", c.Config.LogLevel))
+	infos = append(infos, fmt.Sprintf("Debug: %v", config.Debug))
+	infos = append(infos, fmt.Sprintf("LogLevel: %v", config.LogLevel))
+	infos = append(infos, fmt.Sprintf("ShutdownTimeout: %s", c.shutdownTimeout()))
+	if config.AdminAddr != "" {
+		infos = append(infos, fmt.Sprintf("AdminAddr: %s", config.AdminAddr))
+	}
 	modules.PrintBoxInfo("Container", infos...)
+	c.reloadMu.Lock()
+	tree := c.configTree
+	c.reloadMu.Unlock()
+	if tree != nil {
+		lib.Log.Infof("[Container] effective configuration:\n%s", tree.Redacted())
+	}
 }
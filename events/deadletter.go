@@ -0,0 +1,263 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+	"github.com/google/uuid"
+)
+
+/**
+
+// 查看、重放与清理死信
+letters, _ := eb.DeadLetters(ctx, events.DeadLetterFilter{Event: "order.created"})
+for _, l := range letters {
+	fmt.Println(l.ID, l.Subscriber, l.Error, l.Attempts)
+}
+err := eb.ReplayDeadLetter(ctx, letters[0].ID) // 成功后删除死信
+n, err := eb.PurgeDeadLetters(ctx, events.DeadLetterFilter{Before: time.Now().Add(-7 * 24 * time.Hour)})
+
+*/
+
+// DeadLetter 重试用尽仍处理失败的消息
+type DeadLetter struct {
+	ID         string          `json:"id"`
+	MessageID  string          `json:"message_id"`
+	Event      string          `json:"event"`
+	Subscriber string          `json:"subscriber"`
+	Data       json.RawMessage `json:"data"`
+	Error      string          `json:"error"`
+	Attempts   int             `json:"attempts"`
+	Timestamp  time.Time       `json:"timestamp"` // 消息发布时间
+	Key        string          `json:"key,omitempty"`
+	Seq        uint64          `json:"seq"`
+	FailedAt   time.Time       `json:"failed_at"`
+}
+
+// DeadLetterFilter 死信查询条件，零值字段不作限制
+type DeadLetterFilter struct {
+	Event      string
+	Subscriber string
+	Before     time.Time // 失败时间早于该时间
+	Limit      int
+}
+
+func (f DeadLetterFilter) match(l *DeadLetter) bool {
+	return (f.Event == "" || l.Event == f.Event) &&
+		(f.Subscriber == "" || l.Subscriber == f.Subscriber) &&
+		(f.Before.IsZero() || l.FailedAt.Before(f.Before))
+}
+
+// DeadLetterStore 死信存储
+type DeadLetterStore interface {
+	AddDeadLetter(ctx context.Context, letter *DeadLetter) error
+	// DeadLetters 按失败时间顺序返回满足条件的死信
+	DeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetter, error)
+	// DeadLetter 返回指定编号的死信，不存在时返回 ErrDeadLetterNotFound
+	DeadLetter(ctx context.Context, id string) (*DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+	// PurgeDeadLetters 删除满足条件的死信，返回删除的数量
+	PurgeDeadLetters(ctx context.Context, filter DeadLetterFilter) (int, error)
+}
+
+// ErrDeadLetterNotFound 死信不存在
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// UseDeadLetters 设置死信存储，未设置时重试用尽的消息只记录日志
+func (eb *EventBus) UseDeadLetters(store DeadLetterStore) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	eb.deadLetters = store
+}
+
+func (eb *EventBus) deadLetterStore() (DeadLetterStore, error) {
+	eb.lock.RLock()
+	defer eb.lock.RUnlock()
+	if eb.deadLetters == nil {
+		return nil, fmt.Errorf("dead letter store is not configured")
+	}
+	return eb.deadLetters, nil
+}
+
+// addDeadLetter 将处理失败的消息写入死信存储
+func (eb *EventBus) addDeadLetter(subscriber string, msg *EventMessage, err error, attempts int) {
+	store, storeErr := eb.deadLetterStore()
+	if storeErr != nil {
+		return
+	}
+	data, marshalErr := json.Marshal(msg.Data)
+	if marshalErr != nil {
+		lib.Log.Errorf("[EventBus] encode dead letter of event %s (%s) failed: %v", msg.Event, msg.ID, marshalErr)
+		return
+	}
+	letter := &DeadLetter{
+		ID:         uuid.NewString(),
+		MessageID:  msg.ID,
+		Event:      msg.Event,
+		Subscriber: subscriber,
+		Data:       data,
+		Error:      err.Error(),
+		Attempts:   attempts,
+		Timestamp:  msg.Timestamp,
+		Key:        msg.Key,
+		Seq:        msg.Seq,
+		FailedAt:   time.Now(),
+	}
+	if err := store.AddDeadLetter(context.Background(), letter); err != nil {
+		lib.Log.Errorf("[EventBus] save dead letter of event %s (%s) failed: %v", msg.Event, msg.ID, err)
+	}
+}
+
+// DeadLetters 查询死信
+func (eb *EventBus) DeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetter, error) {
+	store, err := eb.deadLetterStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.DeadLetters(ctx, filter)
+}
+
+// DeadLetter 返回指定编号的死信
+func (eb *EventBus) DeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	store, err := eb.deadLetterStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.DeadLetter(ctx, id)
+}
+
+// ReplayDeadLetter 将死信重新交给原订阅者同步处理一次（不重试），成功后删除死信，
+// 失败时死信保留并返回错误；原订阅者（含同名的持久化订阅）不存在时返回错误
+func (eb *EventBus) ReplayDeadLetter(ctx context.Context, id string) error {
+	store, err := eb.deadLetterStore()
+	if err != nil {
+		return err
+	}
+	letter, err := store.DeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	var target *delivery
+	for _, sub := range eb.matching(letter.Event) {
+		if sub.name == letter.Subscriber {
+			target = newDelivery(sub, nil)
+			break
+		}
+	}
+	if target == nil {
+		// 持久化订阅的死信交给同名的持久化订阅处理
+		eb.lock.RLock()
+		if sub, ok := eb.durable[letter.Subscriber]; ok && matchPattern(sub.pattern, letter.Event) {
+			target = sub.delivery(nil)
+		}
+		eb.lock.RUnlock()
+	}
+	if target == nil {
+		return fmt.Errorf("replay dead letter %s: subscriber '%s' of event %s not found", id, letter.Subscriber, letter.Event)
+	}
+	msg := &EventMessage{
+		ID:        letter.MessageID,
+		Event:     letter.Event,
+		Timestamp: letter.Timestamp,
+		Data:      letter.Data,
+		Attempt:   letter.Attempts + 1,
+		Key:       letter.Key,
+		Seq:       letter.Seq,
+	}
+	if err := invokeHandler(target.handle, msg); err != nil {
+		eb.reportFailure(&Failure{Event: msg.Event, MessageID: msg.ID, Subscriber: target.name, Attempt: msg.Attempt, Err: err})
+		return fmt.Errorf("replay dead letter %s: %w", id, err)
+	}
+	return store.DeleteDeadLetter(ctx, id)
+}
+
+// PurgeDeadLetters 删除满足条件的死信
+func (eb *EventBus) PurgeDeadLetters(ctx context.Context, filter DeadLetterFilter) (int, error) {
+	store, err := eb.deadLetterStore()
+	if err != nil {
+		return 0, err
+	}
+	return store.PurgeDeadLetters(ctx, filter)
+}
+
+// MemoryDeadLetters 内存中的死信存储，进程退出后丢失，最多保留 limit 条，超出时删除最早的死信
+type MemoryDeadLetters struct {
+	mu      sync.Mutex
+	letters []*DeadLetter
+	limit   int
+}
+
+// NewMemoryDeadLetters 创建内存死信存储，limit 不大于 0 时为 10000
+func NewMemoryDeadLetters(limit ...int) *MemoryDeadLetters {
+	m := &MemoryDeadLetters{limit: 10000}
+	if len(limit) > 0 && limit[0] > 0 {
+		m.limit = limit[0]
+	}
+	return m
+}
+
+func (m *MemoryDeadLetters) AddDeadLetter(ctx context.Context, letter *DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, letter)
+	if over := len(m.letters) - m.limit; over > 0 {
+		m.letters = append(m.letters[:0:0], m.letters[over:]...)
+	}
+	return nil
+}
+
+func (m *MemoryDeadLetters) DeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*DeadLetter
+	for _, l := range m.letters {
+		if filter.match(l) {
+			res = append(res, l)
+			if filter.Limit > 0 && len(res) >= filter.Limit {
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+func (m *MemoryDeadLetters) DeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, l := range m.letters {
+		if l.ID == id {
+			return l, nil
+		}
+	}
+	return nil, ErrDeadLetterNotFound
+}
+
+func (m *MemoryDeadLetters) DeleteDeadLetter(ctx context.Context, id string) error {
+	_, err := m.purge(func(l *DeadLetter) bool { return l.ID == id })
+	return err
+}
+
+func (m *MemoryDeadLetters) PurgeDeadLetters(ctx context.Context, filter DeadLetterFilter) (int, error) {
+	return m.purge(filter.match)
+}
+
+func (m *MemoryDeadLetters) purge(match func(*DeadLetter) bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.letters[:0]
+	removed := 0
+	for _, l := range m.letters {
+		if match(l) {
+			removed++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	m.letters = kept
+	return removed, nil
+}
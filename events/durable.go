@@ -0,0 +1,279 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 持久化订阅：消息先写入 SQLite 再投递，处理器正常返回后确认，
+// 进程退出时未确认的消息在重新订阅后再次投递（至少一次）
+store, err := events.NewSQLStore(dbService.GetConnection())
+if err != nil {
+	return err
+}
+if err := eb.UseStore(ctx, store); err != nil {
+	return err
+}
+eb.SubscribeDurable("order.created", "billing", func(msg *events.EventMessage) {
+	var order Order
+	msg.Unmarshal(&order) // 重新投递时 Data 为 JSON，使用 Unmarshal 读取
+	createInvoice(order)  // 处理器应当幂等，msg.Attempt > 1 表示重新投递
+})
+if err := eb.Publish("order.created", order); err != nil {
+	return err // 保存失败时返回 *events.ErrPersist，消息未投递
+}
+
+*/
+
+// durableSub 持久化订阅
+type durableSub struct {
+	name    string
+	pattern string
+	handle  EventHandlerE
+	retry   *RetryPolicy
+}
+
+func (sub *durableSub) delivery(msg *EventMessage) *delivery {
+	return &delivery{name: sub.name, handle: sub.handle, retry: sub.retry, msg: msg}
+}
+
+// UseStore 设置持久化订阅的事件存储，加载存储中的订阅者，
+// 并重新投递已有持久化订阅未确认的消息
+func (eb *EventBus) UseStore(ctx context.Context, store Store) error {
+	subscribers, err := store.Subscribers(ctx)
+	if err != nil {
+		return fmt.Errorf("load durable subscribers: %w", err)
+	}
+	eb.lock.Lock()
+	eb.store = store
+	for name, pattern := range subscribers {
+		if _, exists := eb.durableAll[name]; !exists {
+			eb.durableAll[name] = pattern
+		}
+	}
+	subs := make([]*durableSub, 0, len(eb.durable))
+	for _, sub := range eb.durable {
+		subs = append(subs, sub)
+	}
+	eb.lock.Unlock()
+
+	for _, sub := range subs {
+		if err := store.SaveSubscriber(ctx, sub.name, sub.pattern); err != nil {
+			return fmt.Errorf("save durable subscriber '%s': %w", sub.name, err)
+		}
+	}
+	return eb.Redeliver(ctx)
+}
+
+// HasStore 是否已设置持久化订阅的事件存储
+func (eb *EventBus) HasStore() bool {
+	eb.lock.RLock()
+	defer eb.lock.RUnlock()
+	return eb.store != nil
+}
+
+// SubscribeDurable 以稳定的订阅者名称持久化订阅事件或模式（如 "order.*"），
+// 订阅者名称在进程重启后保持不变才能收到此前未确认的消息。
+// 已设置存储时立即重新投递该订阅者未确认的消息；同名订阅替换原处理器。
+// opts 中只有 WithRetry 生效，重试用尽后设置了死信存储时写入死信并确认，否则保留消息等待重新投递
+func (eb *EventBus) SubscribeDurable(pattern, name string, handler EventHandler, opts ...SubscribeOption) error {
+	if handler == nil {
+		return fmt.Errorf("durable subscription requires pattern, name and handler")
+	}
+	return eb.SubscribeDurableE(pattern, name, wrapHandler(handler), opts...)
+}
+
+// SubscribeDurableE 与 SubscribeDurable 相同，处理器返回错误时按 WithRetry 重试
+func (eb *EventBus) SubscribeDurableE(pattern, name string, handler EventHandlerE, opts ...SubscribeOption) error {
+	if pattern == "" || name == "" || handler == nil {
+		return fmt.Errorf("durable subscription requires pattern, name and handler")
+	}
+	options := &subscriber{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	sub := &durableSub{name: name, pattern: pattern, handle: handler, retry: options.retry}
+	eb.lock.Lock()
+	eb.durable[name] = sub
+	eb.durableAll[name] = pattern
+	store := eb.store
+	eb.lock.Unlock()
+	if store == nil {
+		return nil
+	}
+	if err := store.SaveSubscriber(context.Background(), name, pattern); err != nil {
+		return fmt.Errorf("save durable subscriber '%s': %w", name, err)
+	}
+	atomic.AddInt64(&eb.inflight, 1)
+	go func() {
+		defer atomic.AddInt64(&eb.inflight, -1)
+		if err := eb.redeliverTo(context.Background(), sub); err != nil {
+			lib.Log.Errorf("[EventBus] redeliver to durable subscriber '%s' failed: %v", name, err)
+		}
+	}()
+	return nil
+}
+
+// UnsubscribeDurable 移除本进程的持久化订阅处理器，订阅者仍保留在存储中，
+// 之后发布的消息在重新订阅时投递
+func (eb *EventBus) UnsubscribeDurable(name string) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	delete(eb.durable, name)
+}
+
+// RemoveDurable 移除持久化订阅并删除存储中该订阅者未确认的消息
+func (eb *EventBus) RemoveDurable(ctx context.Context, name string) error {
+	eb.lock.Lock()
+	delete(eb.durable, name)
+	delete(eb.durableAll, name)
+	store := eb.store
+	eb.lock.Unlock()
+	if store == nil {
+		return nil
+	}
+	return store.DeleteSubscriber(ctx, name)
+}
+
+// Redeliver 按发布顺序重新投递本进程持久化订阅未确认的消息，
+// 正在投递的消息会被跳过；每个订阅者依次处理，返回时投递已完成
+func (eb *EventBus) Redeliver(ctx context.Context) error {
+	eb.lock.RLock()
+	subs := make([]*durableSub, 0, len(eb.durable))
+	for _, sub := range eb.durable {
+		subs = append(subs, sub)
+	}
+	eb.lock.RUnlock()
+	for _, sub := range subs {
+		if err := eb.redeliverTo(ctx, sub); err != nil {
+			return fmt.Errorf("redeliver to durable subscriber '%s': %w", sub.name, err)
+		}
+	}
+	return nil
+}
+
+func (eb *EventBus) redeliverTo(ctx context.Context, sub *durableSub) error {
+	eb.lock.RLock()
+	store := eb.store
+	eb.lock.RUnlock()
+	if store == nil {
+		return nil
+	}
+	messages, err := store.Pending(ctx, sub.name, 0)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !eb.markDelivering(sub.name, msg.ID) {
+			continue
+		}
+		lib.Log.Infof("[EventBus] redeliver event %s (%s) to '%s', attempt %d", msg.Event, msg.ID, sub.name, msg.Attempt)
+		eb.deliverDurable(sub, msg)
+	}
+	return nil
+}
+
+// publishDurable 保存消息并返回需要立即投递的持久化订阅，没有存储时返回 nil；
+// 保存失败时返回 *ErrPersist，调用方不应投递该消息
+func (eb *EventBus) publishDurable(msg *EventMessage) ([]*durableSub, error) {
+	eb.lock.Lock()
+	if eb.store == nil {
+		eb.lock.Unlock()
+		return nil, nil
+	}
+	store := eb.store
+	var names []string
+	var subs []*durableSub
+	delivered := make(map[string]bool)
+	for name, pattern := range eb.durableAll {
+		if !matchPattern(pattern, msg.Event) {
+			continue
+		}
+		names = append(names, name)
+		if sub, ok := eb.durable[name]; ok {
+			subs = append(subs, sub)
+			delivered[name] = true
+			eb.delivering[deliveryKey(name, msg.ID)] = true
+		}
+	}
+	eb.lock.Unlock()
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if err := store.Append(context.Background(), msg, names, delivered); err != nil {
+		for _, sub := range subs {
+			eb.unmarkDelivering(sub.name, msg.ID)
+		}
+		return nil, &ErrPersist{Event: msg.Event, MessageID: msg.ID, Err: err}
+	}
+	return subs, nil
+}
+
+// ErrPersist 事件有持久化订阅，但保存到事件存储失败，消息未投递
+type ErrPersist struct {
+	Event     string
+	MessageID string
+	Err       error
+}
+
+func (e *ErrPersist) Error() string {
+	return fmt.Sprintf("persist event %s (%s) failed: %v", e.Event, e.MessageID, e.Err)
+}
+
+func (e *ErrPersist) Unwrap() error { return e.Err }
+
+// deliverDurable 在当前 goroutine 中按重试策略调用持久化订阅的处理器并结束投递
+func (eb *EventBus) deliverDurable(sub *durableSub, msg *EventMessage) error {
+	d := sub.delivery(msg)
+	for wait := eb.attempt(d, false); wait > 0; wait = eb.attempt(d, false) {
+		time.Sleep(wait)
+	}
+	eb.completeDurable(sub, d)
+	return d.err
+}
+
+// completeDurable 结束持久化消息的投递：成功时确认；最终失败时设置了死信存储则写入死信并确认，
+// 否则保留消息等待重新投递
+func (eb *EventBus) completeDurable(sub *durableSub, d *delivery) {
+	defer eb.unmarkDelivering(sub.name, d.msg.ID)
+	if d.err != nil {
+		if _, err := eb.deadLetterStore(); err != nil {
+			return
+		}
+		eb.deadLetter(d)
+	}
+	eb.lock.RLock()
+	store := eb.store
+	eb.lock.RUnlock()
+	if err := store.Ack(context.Background(), d.msg.ID, sub.name); err != nil {
+		lib.Log.Errorf("[EventBus] ack event %s (%s) for '%s' failed: %v", d.msg.Event, d.msg.ID, sub.name, err)
+	}
+}
+
+func (eb *EventBus) markDelivering(name, id string) bool {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	key := deliveryKey(name, id)
+	if eb.delivering[key] {
+		return false
+	}
+	eb.delivering[key] = true
+	return true
+}
+
+func (eb *EventBus) unmarkDelivering(name, id string) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	delete(eb.delivering, deliveryKey(name, id))
+}
+
+func deliveryKey(name, id string) string { return name + "\x00" + id }
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestStore(t *testing.T, path string) *SQLStore {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	store, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+type order struct {
+	Id     int
+	Amount float64
+}
+
+func TestEventBus_DurableRedeliveryAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	ctx := context.Background()
+
+	// 第一个进程：处理器 panic，消息未确认
+	eb := NewEventBus()
+	if err := eb.UseStore(ctx, openTestStore(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	eb.SubscribeDurable("order.*", "billing", func(msg *EventMessage) { panic("crash") })
+	eb.Drain(ctx)
+	eb.SyncPublish("order.created", order{Id: 1, Amount: 9.5})
+	eb.UnsubscribeDurable("billing")
+	// 订阅者离线期间发布的消息同样保留
+	eb.SyncPublish("order.paid", order{Id: 2}, WithPartitionKey("2"))
+	eb.SyncPublish("user.created", order{Id: 3})
+
+	// 重启：新的总线与存储连接
+	received := make(chan *EventMessage, 4)
+	eb = NewEventBus()
+	if err := eb.UseStore(ctx, openTestStore(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	eb.SubscribeDurable("order.*", "billing", func(msg *EventMessage) { received <- msg })
+
+	var got []*EventMessage
+	for len(got) < 2 {
+		select {
+		case msg := <-received:
+			got = append(got, msg)
+		case <-time.After(time.Second):
+			t.Fatalf("expected 2 redelivered messages, got %d", len(got))
+		}
+	}
+	var first order
+	if err := got[0].Unmarshal(&first); err != nil || first.Id != 1 || first.Amount != 9.5 {
+		t.Errorf("unexpected redelivered data %+v, %v", first, err)
+	}
+	if got[0].Event != "order.created" || got[0].Attempt != 2 {
+		t.Errorf("expected order.created on attempt 2, got %s attempt %d", got[0].Event, got[0].Attempt)
+	}
+	if got[1].Event != "order.paid" || got[1].Attempt != 1 || got[1].Key != "2" || got[1].Seq != 1 {
+		t.Errorf("expected order.paid with key 2 on attempt 1, got %s key %q seq %d attempt %d", got[1].Event, got[1].Key, got[1].Seq, got[1].Attempt)
+	}
+
+	if err := eb.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	store := openTestStore(t, path)
+	if pending, err := store.Pending(ctx, "billing", 0); err != nil || len(pending) != 0 {
+		t.Errorf("acknowledged messages should not be pending: %d, %v", len(pending), err)
+	}
+}
+
+func TestEventBus_DurablePublishAck(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t, filepath.Join(t.TempDir(), "events.db"))
+	eb := NewEventBus()
+	done := make(chan struct{})
+	eb.SubscribeDurable("order.created", "audit", func(msg *EventMessage) { close(done) })
+	if err := eb.UseStore(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	eb.Publish("order.created", order{Id: 1})
+	<-done
+	if err := eb.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := store.Pending(ctx, "audit", 0); len(pending) != 0 {
+		t.Errorf("message should be acknowledged, %d pending", len(pending))
+	}
+
+	if err := eb.RemoveDurable(ctx, "audit"); err != nil {
+		t.Fatal(err)
+	}
+	eb.SyncPublish("order.created", order{Id: 2})
+	if subscribers, _ := store.Subscribers(ctx); len(subscribers) != 0 {
+		t.Errorf("removed subscriber should be deleted: %v", subscribers)
+	}
+}
+
+func TestEventBus_DurableRetryAndDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	eb := NewEventBus()
+	eb.UseDeadLetters(NewMemoryDeadLetters())
+	store := openTestStore(t, filepath.Join(t.TempDir(), "events.db"))
+	if err := eb.UseStore(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	var attempts []int
+	eb.SubscribeDurable("order.*", "billing", func(msg *EventMessage) {
+		attempts = append(attempts, msg.Attempt)
+		panic("billing down")
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+	eb.Drain(ctx)
+	eb.SyncPublish("order.created", order{Id: 1})
+
+	if len(attempts) != 2 || attempts[1] != 2 {
+		t.Errorf("expected 2 attempts, got %v", attempts)
+	}
+	letters, _ := eb.DeadLetters(ctx, DeadLetterFilter{Subscriber: "billing"})
+	if len(letters) != 1 || letters[0].Attempts != 2 {
+		t.Fatalf("expected a dead letter after retries, got %+v", letters)
+	}
+	if pending, _ := store.Pending(ctx, "billing", 0); len(pending) != 0 {
+		t.Errorf("dead-lettered message should be acked, got %d pending", len(pending))
+	}
+
+	// 死信可以交给同名的持久化订阅重新处理
+	eb.SubscribeDurable("order.*", "billing", func(msg *EventMessage) {})
+	if err := eb.ReplayDeadLetter(ctx, letters[0].ID); err != nil {
+		t.Error(err)
+	}
+}
+
+// failingStore 保存消息总是失败的事件存储
+type failingStore struct {
+	Store
+}
+
+func (failingStore) Append(ctx context.Context, msg *EventMessage, subscribers []string, delivered map[string]bool) error {
+	return errors.New("disk full")
+}
+
+func TestEventBus_DurablePersistFailure(t *testing.T) {
+	ctx := context.Background()
+	eb := NewEventBus()
+	if err := eb.UseStore(ctx, failingStore{openTestStore(t, filepath.Join(t.TempDir(), "events.db"))}); err != nil {
+		t.Fatal(err)
+	}
+	var received int32
+	eb.SubscribeDurable("order.*", "billing", func(msg *EventMessage) { atomic.AddInt32(&received, 1) })
+	eb.Subscribe("order.created", func(msg *EventMessage) { atomic.AddInt32(&received, 1) })
+	eb.Drain(ctx)
+
+	var persistErr *ErrPersist
+	if err := eb.Publish("order.created", order{Id: 1}); !errors.As(err, &persistErr) || persistErr.Err.Error() != "disk full" {
+		t.Errorf("expected *ErrPersist, got %v", err)
+	}
+	if _, err := eb.PublishAndWait(ctx, "order.created", order{Id: 1}); !errors.As(err, &persistErr) {
+		t.Errorf("expected *ErrPersist from PublishAndWait, got %v", err)
+	}
+	// 没有持久化订阅的事件不受影响
+	if err := eb.Publish("user.created", nil); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	eb.Drain(ctx)
+	if n := atomic.LoadInt32(&received); n != 0 {
+		t.Errorf("event that failed to persist should not be delivered, got %d deliveries", n)
+	}
+}
@@ -1,9 +1,14 @@
 package events
 
 import (
+	"container/list"
 	"context"
+	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gloopai/gloop/lib"
@@ -15,8 +20,16 @@ import (
 // EventMessage 事件消息结构体
 type EventMessage struct {
 	ID        string      // 消息编号
+	Event     string      // 事件名称
 	Timestamp time.Time   // 消息时间
-	Data      interface{} // 消息数据
+	Data      interface{} // 消息数据，持久化订阅重新投递时为 json.RawMessage
+	Attempt   int         // 投递次数，首次投递为 1
+	Key       string      // 分区键，WithPartitionKey 设置
+	Seq       uint64      // 同一事件与分区键内从 1 开始递增的序号，用于检测丢失的消息，回复消息为 0，长时间未发布的分区键从 1 重新开始
+
+	CorrelationID string // 回复消息对应的请求消息 ID
+	ReplyTo       string // 请求消息的回复地址，使用 EventBus.Reply 回复
+	Origin        string // 发布消息的节点 ID，本节点发布的消息为空
 }
 
 // Data 反序列化
@@ -27,6 +40,9 @@ func (e *EventMessage) Unmarshal(v interface{}) error {
 // EventHandler defines the function signature for event handlers.
 type EventHandler func(msg *EventMessage)
 
+// EventHandlerE 返回错误的事件处理器，返回错误或 panic 视为处理失败，按订阅的重试策略重试
+type EventHandlerE func(msg *EventMessage) error
+
 // EventHandlerWithContext defines the function signature for event handlers with context and generic type.
 type EventHandlerWithContext[T any] func(ctx context.Context, data T)
 
@@ -35,65 +51,143 @@ type LoggerHook func(event string, action string, info map[string]interface{})
 
 // EventBus manages event subscriptions and publishing.
 type EventBus struct {
-	listeners       map[string][]EventHandler
-	patternHandlers []patternHandler
-	lock            sync.RWMutex
+	listeners map[string][]*subscriber
+	patterns  topicTrie[*subscriber] // 模式订阅
+	subSeq    uint64                 // 订阅顺序
+	lock      sync.RWMutex
+	inflight  int64       // 正在执行与排队的异步处理器数量
+	pool      *workerPool // 全局工作池，SetPool 设置
+
+	ordering sync.Mutex // 带分区键的消息按序号顺序入队
+	seqLock  sync.Mutex
+	seqs     map[string]*list.Element // 事件与分区键的最新序号，元素为 *seqEntry
+	seqOrder *list.List               // 最近发布的在前，超过 seqLimit 时淘汰最久未发布的
+	seqLimit int
+
+	failureHooks []FailureHook   // 处理失败时调用
+	deadLetters  DeadLetterStore // 重试用尽的消息，UseDeadLetters 设置
+
+	store      Store                  // 持久化订阅的事件存储，UseStore 设置
+	durable    map[string]*durableSub // 本进程的持久化订阅，按订阅者名称索引
+	durableAll map[string]string      // 所有持久化订阅者（含存储中尚未重新订阅的），名称到事件或模式
+	delivering map[string]bool        // 正在投递的持久化消息，避免重新投递时重复
+
+	inboxes map[string]func(*EventMessage) // 进行中的请求，按回复地址索引
 }
 
 // GenericEventBus 支持泛型、超时、日志钩子的事件总线。
 type GenericEventBus[T any] struct {
-	listeners       map[string][]EventHandlerWithContext[T]
-	patternHandlers []struct {
-		pattern string
-		handler EventHandlerWithContext[T]
-	}
-	lock   sync.RWMutex
-	logger LoggerHook
+	listeners map[string][]EventHandlerWithContext[T]
+	patterns  topicTrie[EventHandlerWithContext[T]]
+	lock      sync.RWMutex
+	logger    LoggerHook
 }
 
-// patternHandler is used for storing pattern-based subscriptions.
-type patternHandler struct {
-	pattern string
-	handler EventHandler
+// subscriber 一个订阅
+type subscriber struct {
+	name    string        // 订阅者名称，用于日志与死信，默认为处理器函数名
+	event   string        // 普通订阅的事件名称
+	pattern string        // 模式订阅的模式，普通订阅为空
+	ptr     uintptr       // 处理器函数指针，仅用于 Unsubscribe(event, handler)
+	handle  EventHandlerE // 实际执行的处理器
+	retry   *RetryPolicy
+	seq     uint64 // 订阅顺序，优先级相同时按订阅顺序执行
+
+	filter        func(msg *EventMessage) bool
+	priority      int
+	maxDeliveries int64
+	deliveries    int64 // 已接收的消息数量
+	expiresAt     time.Time
+	group         *SubscriptionGroup
+	removed       atomic.Bool
+
+	poolConfig *PoolConfig // WithPool 设置
+	pool       *workerPool // 独立工作池，为 nil 时使用全局工作池
 }
 
 // NewEventBus creates a new EventBus instance.
+// 异步处理器在 DefaultPoolConfig 的全局工作池中执行，可以使用 SetPool 修改
 func NewEventBus() *EventBus {
-	return &EventBus{
-		listeners:       make(map[string][]EventHandler),
-		patternHandlers: []patternHandler{},
+	eb := &EventBus{
+		listeners:  make(map[string][]*subscriber),
+		durable:    make(map[string]*durableSub),
+		durableAll: make(map[string]string),
+		delivering: make(map[string]bool),
+		inboxes:    make(map[string]func(*EventMessage)),
+		seqs:       make(map[string]*list.Element),
+		seqOrder:   list.New(),
+		seqLimit:   defaultSequenceLimit,
 	}
+	eb.pool = newWorkerPool("global", DefaultPoolConfig, eb.done)
+	return eb
 }
 
 // NewGenericEventBus 创建泛型事件总线。
 func NewGenericEventBus[T any](logger LoggerHook) *GenericEventBus[T] {
 	return &GenericEventBus[T]{
 		listeners: make(map[string][]EventHandlerWithContext[T]),
-		patternHandlers: []struct {
-			pattern string
-			handler EventHandlerWithContext[T]
-		}{},
-		logger: logger,
+		logger:    logger,
 	}
 }
 
-// Subscribe adds a handler for a specific event.
-// If event is empty or handler is nil, it does nothing.
-// If the handler is already subscribed to the event, it will not be added again.
-func (eb *EventBus) Subscribe(event string, handler EventHandler) {
-	// lib.Log.Debugf("Subscribe event: %s, handler: %v", event, handler)
-	if event == "" || handler == nil {
-		return
+// Subscribe adds a handler for a specific event and returns its Subscription.
+// If event is empty or handler is nil, it does nothing and returns nil.
+// 每次调用都是独立的订阅，同一处理器订阅两次会收到两次消息。
+// handler 的 panic 视为处理失败，可以使用 WithRetry 重试
+func (eb *EventBus) Subscribe(event string, handler EventHandler, opts ...SubscribeOption) *Subscription {
+	if handler == nil {
+		return nil
 	}
-	ptr := getFuncPointer(handler)
-	eb.lock.Lock()
-	defer eb.lock.Unlock()
-	for _, h := range eb.listeners[event] {
-		if getFuncPointer(h) == ptr {
-			return // already subscribed
+	return eb.subscribe(event, "", getFuncPointer(handler), wrapHandler(handler), opts)
+}
+
+// SubscribeE 订阅事件，处理器返回错误时按 WithRetry 的策略重试，重试用尽后写入死信
+func (eb *EventBus) SubscribeE(event string, handler EventHandlerE, opts ...SubscribeOption) *Subscription {
+	if handler == nil {
+		return nil
+	}
+	return eb.subscribe(event, "", reflect.ValueOf(handler).Pointer(), handler, opts)
+}
+
+// subscribe 添加订阅，pattern 不为空时为模式订阅
+func (eb *EventBus) subscribe(event, pattern string, ptr uintptr, handle EventHandlerE, opts []SubscribeOption) *Subscription {
+	if event == "" && pattern == "" {
+		return nil
+	}
+	sub := &subscriber{event: event, pattern: pattern, ptr: ptr, handle: handle}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	if sub.name == "" {
+		if fn := runtime.FuncForPC(ptr); fn != nil {
+			sub.name = fn.Name()
 		}
 	}
-	eb.listeners[event] = append(eb.listeners[event], handler)
+	if sub.poolConfig != nil {
+		sub.pool = newWorkerPool(sub.name, *sub.poolConfig, eb.done)
+	}
+	s := &Subscription{bus: eb, sub: sub}
+	eb.lock.Lock()
+	eb.subSeq++
+	sub.seq = eb.subSeq
+	if pattern != "" {
+		eb.patterns.add(pattern, sub)
+	} else {
+		eb.listeners[event] = append(eb.listeners[event], sub)
+	}
+	eb.lock.Unlock()
+	if sub.group != nil {
+		sub.group.Add(s)
+	}
+	return s
+}
+
+// wrapHandler 将 EventHandler 转换为 EventHandlerE
+func wrapHandler(handler EventHandler) EventHandlerE {
+	return func(msg *EventMessage) error {
+		handler(msg)
+		return nil
+	}
 }
 
 // Subscribe 订阅事件。
@@ -115,19 +209,48 @@ func (eb *GenericEventBus[T]) Subscribe(event string, handler EventHandlerWithCo
 	}
 }
 
+// SubscribePattern 订阅匹配模式的事件，模式规则与 EventBus.SubscribePattern 相同。
+func (eb *GenericEventBus[T]) SubscribePattern(pattern string, handler EventHandlerWithContext[T]) {
+	if pattern == "" || handler == nil {
+		return
+	}
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	for _, h := range eb.patterns.get(pattern) {
+		if reflect.ValueOf(h).Pointer() == reflect.ValueOf(handler).Pointer() {
+			return
+		}
+	}
+	eb.patterns.add(pattern, handler)
+	if eb.logger != nil {
+		eb.logger(pattern, "subscribe", nil)
+	}
+}
+
 // Unsubscribe removes a handler for a specific event.
 // If event is empty or handler is nil, it does nothing
+//
+// Deprecated: 按函数指针比较，无法区分同一函数字面量创建的不同闭包，使用 Subscription.Unsubscribe
 func (eb *EventBus) Unsubscribe(event string, handler EventHandler) {
 	// lib.Log.Debugf("Unsubscribe event: %s, handler: %v", event, handler)
 	if event == "" || handler == nil {
 		return
 	}
+	eb.removeByPointer(event, "", getFuncPointer(handler))
+}
+
+// remove 移除第一个满足 match 的订阅
+func (eb *EventBus) remove(event, pattern string, match func(*subscriber) bool) {
 	eb.lock.Lock()
 	defer eb.lock.Unlock()
+	if pattern != "" {
+		eb.patterns.remove(pattern, match)
+		return
+	}
 	handlers := eb.listeners[event]
-	for i, h := range handlers {
-		if getFuncPointer(h) == getFuncPointer(handler) {
-			eb.listeners[event] = append(handlers[:i], handlers[i+1:]...)
+	for i, s := range handlers {
+		if match(s) {
+			eb.listeners[event] = append(handlers[:i:i], handlers[i+1:]...)
 			break
 		}
 	}
@@ -136,76 +259,199 @@ func (eb *EventBus) Unsubscribe(event string, handler EventHandler) {
 	}
 }
 
+// removeByPointer 取消第一个处理器指针相同的订阅
+func (eb *EventBus) removeByPointer(event, pattern string, ptr uintptr) {
+	eb.lock.RLock()
+	candidates := eb.listeners[event]
+	if pattern != "" {
+		candidates = eb.patterns.get(pattern)
+	}
+	var found *subscriber
+	for _, s := range candidates {
+		if s.ptr == ptr {
+			found = s
+			break
+		}
+	}
+	eb.lock.RUnlock()
+	if found != nil {
+		eb.unsubscribe(found)
+	}
+}
+
 // Once subscribes a handler that will be called only once for the event.
-func (eb *EventBus) Once(event string, handler EventHandler) {
+func (eb *EventBus) Once(event string, handler EventHandler, opts ...SubscribeOption) *Subscription {
 	// lib.Log.Debugf("Once event: %s, handler: %v", event, handler)
-	var wrapper EventHandler
-	wrapper = func(msg *EventMessage) {
-		eb.Unsubscribe(event, wrapper)
-		handler(msg)
+	if event == "" || handler == nil {
+		return nil
 	}
-	eb.Subscribe(event, wrapper)
+	return eb.Subscribe(event, handler, append(opts, WithMaxDeliveries(1))...)
 }
 
 // SubscribePattern adds a handler for events matching a pattern (e.g. "user.*").
-// 支持简单的前缀通配符（如 "user.*" 匹配所有以 "user." 开头的事件）。
-func (eb *EventBus) SubscribePattern(pattern string, handler EventHandler) {
+// 事件名称按 "." 分段，"*" 匹配一段（"user.*" 匹配 "user.create"，不匹配 "user.profile.update"），
+// 放在最后的 ">" 或 "#" 匹配一段或多段（"user.>" 匹配两者）。
+func (eb *EventBus) SubscribePattern(pattern string, handler EventHandler, opts ...SubscribeOption) *Subscription {
 	// lib.Log.Debugf("SubscribePattern pattern: %s, handler: %v", pattern, handler)
 	if pattern == "" || handler == nil {
-		return
+		return nil
 	}
-	eb.lock.Lock()
-	defer eb.lock.Unlock()
-	for _, ph := range eb.patternHandlers {
-		if ph.pattern == pattern && getFuncPointer(ph.handler) == getFuncPointer(handler) {
-			return // already subscribed
-		}
+	return eb.subscribe("", pattern, getFuncPointer(handler), wrapHandler(handler), opts)
+}
+
+// SubscribePatternE 订阅匹配模式的事件，处理器可以返回错误
+func (eb *EventBus) SubscribePatternE(pattern string, handler EventHandlerE, opts ...SubscribeOption) *Subscription {
+	if pattern == "" || handler == nil {
+		return nil
 	}
-	eb.patternHandlers = append(eb.patternHandlers, patternHandler{pattern, handler})
+	return eb.subscribe("", pattern, reflect.ValueOf(handler).Pointer(), handler, opts)
 }
 
 // UnsubscribePattern removes a pattern handler.
+//
+// Deprecated: 使用 Subscription.Unsubscribe
 func (eb *EventBus) UnsubscribePattern(pattern string, handler EventHandler) {
 	// lib.Log.Debugf("UnsubscribePattern pattern: %s, handler: %v", pattern, handler)
 	if pattern == "" || handler == nil {
 		return
 	}
-	eb.lock.Lock()
-	defer eb.lock.Unlock()
-	for i, ph := range eb.patternHandlers {
-		if ph.pattern == pattern && getFuncPointer(ph.handler) == getFuncPointer(handler) {
-			eb.patternHandlers = append(eb.patternHandlers[:i], eb.patternHandlers[i+1:]...)
-			break
-		}
-	}
+	eb.removeByPointer("", pattern, getFuncPointer(handler))
 }
 
 // Publish triggers all handlers subscribed to an event.
-func (eb *EventBus) Publish(event string, data interface{}) {
+// 处理器在工作池中异步执行，队列已满时按工作池的溢出策略处理，被丢弃或拒绝的消息写入死信，
+// 策略为 OverflowError 时返回第一个 *ErrQueueFull，其余处理器仍会投递；
+// 事件有持久化订阅而保存失败时返回 *ErrPersist，消息不投递给任何处理器
+func (eb *EventBus) Publish(event string, data interface{}, opts ...PublishOption) error {
 	// lib.Log.Debugf("Publish event: %s, data: %v", event, data)
+	return eb.publish(newMessage(event, data, opts), nil)
+}
+
+// publish 将消息交给持久化订阅与匹配的订阅异步处理，w 不为 nil 时记录每个处理器的结果
+func (eb *EventBus) publish(msg *EventMessage, w *waiter) error {
+	handlers := eb.matching(msg.Event)
+	if msg.Key != "" {
+		eb.ordering.Lock()
+		defer eb.ordering.Unlock()
+	}
+	eb.sequence(msg)
+	durable, err := eb.publishDurable(msg)
+	if err != nil {
+		return err
+	}
 	eb.lock.RLock()
-	handlers := append([]EventHandler(nil), eb.listeners[event]...)
-	for _, ph := range eb.patternHandlers {
-		if matchPattern(ph.pattern, event) {
-			handlers = append(handlers, ph.handler)
+	global := eb.pool
+	eb.lock.RUnlock()
+	var firstErr error
+	submit := func(pool *workerPool, j *job, d *delivery, complete func(d *delivery)) {
+		i := w.add(j.subscriber)
+		if w != nil {
+			j.ctx = w.ctx
+		}
+		var start time.Time
+		j.run = func(canceled bool) time.Duration {
+			if start.IsZero() {
+				start = time.Now()
+			}
+			if wait := eb.attempt(d, canceled); wait > 0 {
+				return wait
+			}
+			complete(d)
+			w.finish(i, d.err, time.Since(start))
+			return 0
+		}
+		drop := j.drop
+		j.drop = func() {
+			if drop != nil {
+				drop()
+			}
+			w.finish(i, &ErrQueueFull{Pool: pool.name, Event: msg.Event}, 0)
+		}
+		atomic.AddInt64(&eb.inflight, 1)
+		if err := pool.submit(j); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
-	eb.lock.RUnlock()
+	for _, sub := range durable {
+		submit(global, &job{
+			event:      msg.Event,
+			id:         msg.ID,
+			subscriber: sub.name,
+			partition:  partitionKey{sub, msg.Key},
+			drop:       func() { eb.unmarkDelivering(sub.name, msg.ID) }, // 仍保存在存储中，等待重新投递
+		}, sub.delivery(msg), func(d *delivery) { eb.completeDurable(sub, d) })
+	}
+	for _, sub := range handlers {
+		if !eb.accept(sub, msg) {
+			continue
+		}
+		pool := global
+		if sub.pool != nil {
+			pool = sub.pool
+		}
+		submit(pool, &job{
+			event:      msg.Event,
+			id:         msg.ID,
+			subscriber: sub.name,
+			partition:  partitionKey{sub, msg.Key},
+			drop: func() {
+				eb.addDeadLetter(sub.name, msg, &ErrQueueFull{Pool: pool.name, Event: msg.Event}, 0)
+			},
+		}, newDelivery(sub, msg), eb.deadLetter)
+	}
+	return firstErr
+}
+
+// PublishRemote 发布来自其他节点的消息，保留消息编号、时间、分区键与请求回复信息，
+// msg.Origin 为来源节点 ID，不能为空；Seq 由本地事件总线重新分配
+func (eb *EventBus) PublishRemote(msg *EventMessage) error {
+	if msg.Origin == "" {
+		return fmt.Errorf("publish remote event %s (%s): origin is empty", msg.Event, msg.ID)
+	}
+	m := *msg
+	m.Attempt, m.Seq = 1, 0
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.Timestamp.IsZero() {
+		m.Timestamp = time.Now()
+	}
+	return eb.publish(&m, nil)
+}
+
+// newMessage 创建消息并应用发布选项
+func newMessage(event string, data interface{}, opts []PublishOption) *EventMessage {
 	msg := &EventMessage{
 		ID:        uuid.NewString(),
+		Event:     event,
 		Timestamp: time.Now(),
 		Data:      data,
+		Attempt:   1,
 	}
-	for _, handler := range handlers {
-		go func(h EventHandler) {
-			defer func() {
-				if r := recover(); r != nil {
-					// 可加日志：fmt.Printf("event handler panic: %v\n", r)
-				}
-			}()
-			h(msg)
-		}(handler)
+	for _, opt := range opts {
+		opt(msg)
 	}
+	return msg
+}
+
+// done 一个异步投递执行完或被丢弃
+func (eb *EventBus) done() { atomic.AddInt64(&eb.inflight, -1) }
+
+// matching 返回订阅了事件的处理器，按优先级从高到低、订阅顺序排列
+func (eb *EventBus) matching(event string) []*subscriber {
+	eb.lock.RLock()
+	defer eb.lock.RUnlock()
+	handlers := append([]*subscriber(nil), eb.listeners[event]...)
+	eb.patterns.match(event, func(s *subscriber) { handlers = append(handlers, s) })
+	if len(handlers) > 1 {
+		sort.Slice(handlers, func(i, j int) bool {
+			if handlers[i].priority != handlers[j].priority {
+				return handlers[i].priority > handlers[j].priority
+			}
+			return handlers[i].seq < handlers[j].seq
+		})
+	}
+	return handlers
 }
 
 // Publish 支持 context、超时、日志。
@@ -213,11 +459,7 @@ func (eb *GenericEventBus[T]) Publish(ctx context.Context, event string, data T,
 	// lib.Log.Debugf("Publish event: %s, data: %v", event, data)
 	eb.lock.RLock()
 	handlers := append([]EventHandlerWithContext[T](nil), eb.listeners[event]...)
-	for _, ph := range eb.patternHandlers {
-		if matchPattern(ph.pattern, event) {
-			handlers = append(handlers, ph.handler)
-		}
-	}
+	eb.patterns.match(event, func(h EventHandlerWithContext[T]) { handlers = append(handlers, h) })
 	eb.lock.RUnlock()
 	for _, handler := range handlers {
 		go func(h EventHandlerWithContext[T]) {
@@ -241,49 +483,59 @@ func (eb *GenericEventBus[T]) Publish(ctx context.Context, event string, data T,
 }
 
 // SyncPublish triggers all handlers synchronously (in the current goroutine).
-// If any handler panic, it will be recovered.
-func (eb *EventBus) SyncPublish(event string, data interface{}) {
+// If any handler panic, it will be recovered. 重试的等待同样在当前 goroutine 中进行；
+// 事件有持久化订阅而保存失败时记录错误日志，消息不投递，需要得到错误时使用 Publish
+func (eb *EventBus) SyncPublish(event string, data interface{}, opts ...PublishOption) {
 	// lib.Log.Debugf("SyncPublish event: %s, data: %v", event, data)
-	eb.lock.RLock()
-	handlers := append([]EventHandler(nil), eb.listeners[event]...)
-	for _, ph := range eb.patternHandlers {
-		if matchPattern(ph.pattern, event) {
-			handlers = append(handlers, ph.handler)
-		}
-	}
-	eb.lock.RUnlock()
-	msg := &EventMessage{
-		ID:        uuid.NewString(),
-		Timestamp: time.Now(),
-		Data:      data,
+	handlers := eb.matching(event)
+	msg := newMessage(event, data, opts)
+	eb.sequence(msg)
+	durable, err := eb.publishDurable(msg)
+	if err != nil {
+		lib.Log.Errorf("[EventBus] %v", err)
+		return
 	}
-	for _, handler := range handlers {
-		func(h EventHandler) {
-			defer func() {
-				if r := recover(); r != nil {
-					// 可加日志：fmt.Printf("event handler panic: %v\n", r)
-				}
-			}()
-			h(msg)
-		}(handler)
+	for _, sub := range durable {
+		eb.deliverDurable(sub, msg)
 	}
-}
-
-// matchPattern 支持简单的前缀通配符（如 "user.*" 匹配 "user.create"）
-func matchPattern(pattern, event string) bool {
-	if len(pattern) > 0 && pattern[len(pattern)-1] == '*' {
-		prefix := pattern[:len(pattern)-1]
-		return len(event) >= len(prefix) && event[:len(prefix)] == prefix
+	for _, sub := range handlers {
+		if eb.accept(sub, msg) {
+			eb.dispatch(sub, msg)
+		}
 	}
-	return pattern == event
 }
 
 // Close removes all event listeners.
+// 等待中的重试被取消，消息写入死信
 func (eb *EventBus) Close() {
+	eb.cancelRetries()
 	eb.lock.Lock()
 	defer eb.lock.Unlock()
-	eb.listeners = make(map[string][]EventHandler)
-	eb.patternHandlers = nil
+	for _, handlers := range eb.listeners {
+		for _, s := range handlers {
+			s.removed.Store(true)
+		}
+	}
+	eb.patterns.each(func(s *subscriber) { s.removed.Store(true) })
+	eb.listeners = make(map[string][]*subscriber)
+	eb.patterns = topicTrie[*subscriber]{}
+	eb.durable = make(map[string]*durableSub)
+}
+
+// Drain waits until all asynchronously running handlers have returned or ctx is done.
+// 等待中的重试计入处理中的处理器，ctx 结束时取消等待，重试放弃后写入死信
+func (eb *EventBus) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&eb.inflight) > 0 {
+		select {
+		case <-ctx.Done():
+			eb.cancelRetries()
+			return fmt.Errorf("event bus drain: %d handlers still running: %w", atomic.LoadInt64(&eb.inflight), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
 }
 
 // HasSubscribers returns true if the event has any subscribers.
@@ -295,6 +547,7 @@ func (eb *EventBus) HasSubscribers(event string) bool {
 }
 
 // EventStats returns a map of event names to their subscriber counts.
+// 工作池的队列深度与延迟见 Stats
 func (eb *EventBus) EventStats() map[string]int {
 	stats := make(map[string]int)
 	eb.lock.RLock()
@@ -305,6 +558,54 @@ func (eb *EventBus) EventStats() map[string]int {
 	return stats
 }
 
+// BusStats 事件总线统计
+type BusStats struct {
+	Subscribers map[string]int `json:"subscribers"` // 与 EventStats 相同
+	Patterns    int            `json:"patterns"`    // 模式订阅数量
+	Inflight    int64          `json:"inflight"`    // 正在执行与排队的异步处理器数量
+	Pools       []PoolStats    `json:"pools"`       // 全局工作池在前，之后为订阅的独立工作池
+}
+
+// Stats 返回订阅数量与各工作池的队列深度、等待时间和处理延迟
+func (eb *EventBus) Stats() BusStats {
+	stats := BusStats{Subscribers: eb.EventStats(), Inflight: atomic.LoadInt64(&eb.inflight)}
+	eb.lock.RLock()
+	stats.Patterns = eb.patterns.size
+	eb.lock.RUnlock()
+	for _, p := range eb.pools() {
+		stats.Pools = append(stats.Pools, p.stats())
+	}
+	sort.SliceStable(stats.Pools[1:], func(i, j int) bool { return stats.Pools[i+1].Name < stats.Pools[j+1].Name })
+	return stats
+}
+
+// pools 返回全局工作池与订阅的独立工作池
+func (eb *EventBus) pools() []*workerPool {
+	eb.lock.RLock()
+	defer eb.lock.RUnlock()
+	pools := []*workerPool{eb.pool}
+	for _, handlers := range eb.listeners {
+		for _, s := range handlers {
+			if s.pool != nil {
+				pools = append(pools, s.pool)
+			}
+		}
+	}
+	eb.patterns.each(func(s *subscriber) {
+		if s.pool != nil {
+			pools = append(pools, s.pool)
+		}
+	})
+	return pools
+}
+
+// cancelRetries 取消所有工作池中等待中的重试
+func (eb *EventBus) cancelRetries() {
+	for _, p := range eb.pools() {
+		p.cancelRetries()
+	}
+}
+
 // getFuncPointer returns the pointer of a function for comparison.
 func getFuncPointer(fn EventHandler) uintptr {
 	return reflect.ValueOf(fn).Pointer()
@@ -106,15 +106,19 @@ func TestEventBus_SubscribeParamCheck(t *testing.T) {
 	}
 }
 
-func TestEventBus_SubscribeDedup(t *testing.T) {
+func TestEventBus_SubscribeClosuresAreIndependent(t *testing.T) {
 	eb := NewEventBus()
-	count := 0
-	h := func(msg *EventMessage) { count++ }
-	eb.Subscribe("evt", h)
-	eb.Subscribe("evt", h)
+	counts := make([]int, 2)
+	newHandler := func(i int) EventHandler {
+		return func(msg *EventMessage) { counts[i]++ }
+	}
+	first := eb.Subscribe("evt", newHandler(0))
+	eb.Subscribe("evt", newHandler(1))
 	eb.SyncPublish("evt", nil)
-	if count != 1 {
-		t.Errorf("handler should only be called once, got %d", count)
+	first.Unsubscribe()
+	eb.SyncPublish("evt", nil)
+	if counts[0] != 1 || counts[1] != 2 {
+		t.Errorf("closures of the same literal should be separate subscriptions, got %v", counts)
 	}
 }
 
@@ -205,3 +209,21 @@ func TestGenericEventBus_Timeout(t *testing.T) {
 		t.Error("handler did not respect timeout")
 	}
 }
+
+func TestEventBus_Drain(t *testing.T) {
+	eb := NewEventBus()
+	release := make(chan struct{})
+	eb.Subscribe("evt", func(msg *EventMessage) { <-release })
+	eb.Publish("evt", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := eb.Drain(ctx); err == nil {
+		t.Error("drain should time out while a handler is running")
+	}
+
+	close(release)
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Errorf("drain should succeed after handlers finish: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package events
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 处理失败时重试 3 次，间隔 100ms、200ms，仍然失败时写入死信
+eb.UseDeadLetters(events.NewMemoryDeadLetters())
+eb.SubscribeE("order.created", func(msg *events.EventMessage) error {
+	var order Order
+	if err := msg.Unmarshal(&order); err != nil {
+		return err
+	}
+	return billing.Charge(order)
+}, events.WithName("billing"), events.WithRetry(events.RetryPolicy{MaxAttempts: 3, Backoff: 100 * time.Millisecond}))
+
+// 失败通知，失败总会以事件名称与消息编号记录日志
+eb.OnFailure(func(f *events.Failure) {
+	if f.Final {
+		alert(f.Event, f.MessageID, f.Err)
+	}
+})
+
+*/
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(*subscriber)
+
+// WithName 设置订阅者名称，用于日志与死信，默认为处理器函数名
+func WithName(name string) SubscribeOption {
+	return func(s *subscriber) { s.name = name }
+}
+
+// WithRetry 设置处理失败时的重试策略，默认不重试
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(s *subscriber) { s.retry = &policy }
+}
+
+// RetryPolicy 处理器的重试策略，重试间隔从 Backoff 开始按倍数增长，不超过 MaxBackoff
+type RetryPolicy struct {
+	MaxAttempts int           // 最多处理次数（含首次），不大于 1 时不重试
+	Backoff     time.Duration // 首次重试等待时间，默认 100 毫秒
+	MaxBackoff  time.Duration // 最长重试等待时间，默认 10 秒
+}
+
+// backoff 第 retries+1 次重试前的等待时间
+func (p *RetryPolicy) backoff(retries int) time.Duration {
+	backoff, max := p.Backoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	for i := 0; i < retries && backoff < max; i++ {
+		backoff *= 2
+	}
+	return min(backoff, max)
+}
+
+// Failure 一次处理失败
+type Failure struct {
+	Event      string
+	MessageID  string
+	Subscriber string
+	Attempt    int   // 第几次处理
+	Final      bool  // 重试已用尽，消息将写入死信
+	Err        error // 处理器返回的错误，panic 时为 *ErrHandlerPanic
+}
+
+// FailureHook 处理失败时的回调，在处理器所在的 goroutine 中同步调用
+type FailureHook func(f *Failure)
+
+// OnFailure 添加处理失败时的回调
+func (eb *EventBus) OnFailure(hook FailureHook) {
+	if hook == nil {
+		return
+	}
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	eb.failureHooks = append(eb.failureHooks, hook)
+}
+
+// reportFailure 记录失败日志并调用失败回调
+func (eb *EventBus) reportFailure(f *Failure) {
+	if f.Final {
+		lib.Log.Errorf("[EventBus] handler '%s' failed on event %s (%s), attempt %d, giving up: %v", f.Subscriber, f.Event, f.MessageID, f.Attempt, f.Err)
+	} else {
+		lib.Log.Warnf("[EventBus] handler '%s' failed on event %s (%s), attempt %d: %v", f.Subscriber, f.Event, f.MessageID, f.Attempt, f.Err)
+	}
+	eb.lock.RLock()
+	hooks := eb.failureHooks
+	eb.lock.RUnlock()
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					lib.Log.Errorf("[EventBus] failure hook panic: %v", r)
+				}
+			}()
+			hook(f)
+		}()
+	}
+}
+
+// delivery 一条消息对一个处理器的投递与重试状态
+type delivery struct {
+	name     string
+	handle   EventHandlerE
+	retry    *RetryPolicy
+	msg      *EventMessage
+	attempts int   // 已处理次数
+	err      error // 最后一次处理的错误
+}
+
+func newDelivery(sub *subscriber, msg *EventMessage) *delivery {
+	return &delivery{name: sub.name, handle: sub.handle, retry: sub.retry, msg: msg}
+}
+
+// attempt 处理一次消息，失败且可以重试时返回下一次重试前的等待时间，否则返回 0，结果见 d.err。
+// canceled 为 true 时（事件总线停机）不再重试，保留上一次的错误
+func (eb *EventBus) attempt(d *delivery, canceled bool) time.Duration {
+	if canceled && d.attempts > 0 {
+		lib.Log.Warnf("[EventBus] retry of handler '%s' on event %s (%s) canceled after %d attempts", d.name, d.msg.Event, d.msg.ID, d.attempts)
+		return 0
+	}
+	m := d.msg
+	if d.attempts > 0 {
+		retry := *d.msg
+		retry.Attempt = d.msg.Attempt + d.attempts
+		m = &retry
+	}
+	d.attempts++
+	if d.err = invokeHandler(d.handle, m); d.err == nil {
+		return 0
+	}
+	final := d.retry == nil || d.attempts >= d.retry.MaxAttempts
+	eb.reportFailure(&Failure{Event: m.Event, MessageID: m.ID, Subscriber: d.name, Attempt: m.Attempt, Final: final, Err: d.err})
+	if final {
+		return 0
+	}
+	return d.retry.backoff(d.attempts - 1)
+}
+
+// dispatch 在当前 goroutine 中按重试策略调用处理器，重试用尽后写入死信，返回最后一次的错误
+func (eb *EventBus) dispatch(sub *subscriber, msg *EventMessage) error {
+	d := newDelivery(sub, msg)
+	for wait := eb.attempt(d, false); wait > 0; wait = eb.attempt(d, false) {
+		time.Sleep(wait)
+	}
+	eb.deadLetter(d)
+	return d.err
+}
+
+// deadLetter 投递最终失败时写入死信
+func (eb *EventBus) deadLetter(d *delivery) {
+	if d.err != nil {
+		eb.addDeadLetter(d.name, d.msg, d.err, d.msg.Attempt+d.attempts-1)
+	}
+}
+
+// invokeHandler 调用处理器，panic 转换为 *ErrHandlerPanic
+func invokeHandler(handle EventHandlerE, msg *EventMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ErrHandlerPanic{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return handle(msg)
+}
+
+// ErrHandlerPanic 事件处理器 panic
+type ErrHandlerPanic struct {
+	Value any
+	Stack []byte
+}
+
+func (e *ErrHandlerPanic) Error() string { return fmt.Sprintf("handler panic: %v", e.Value) }
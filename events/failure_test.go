@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventBus_RetryThenSucceed(t *testing.T) {
+	eb := NewEventBus()
+	var attempts []int
+	eb.SubscribeE("evt", func(msg *EventMessage) error {
+		attempts = append(attempts, msg.Attempt)
+		if msg.Attempt < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 5, Backoff: time.Millisecond}))
+
+	var failures []*Failure
+	eb.OnFailure(func(f *Failure) { failures = append(failures, f) })
+	eb.SyncPublish("evt", nil)
+
+	if len(attempts) != 3 || attempts[2] != 3 {
+		t.Errorf("expected 3 attempts, got %v", attempts)
+	}
+	if len(failures) != 2 || failures[0].Event != "evt" || failures[0].MessageID == "" || failures[1].Final {
+		t.Errorf("unexpected failures: %+v", failures)
+	}
+}
+
+func TestEventBus_DeadLetterReplayAndPurge(t *testing.T) {
+	eb := NewEventBus()
+	eb.UseDeadLetters(NewMemoryDeadLetters())
+
+	var mu sync.Mutex
+	healthy := false
+	var received []string
+	eb.SubscribeE("order.created", func(msg *EventMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy {
+			return errors.New("billing down")
+		}
+		var o order
+		msg.Unmarshal(&o)
+		received = append(received, msg.ID)
+		return nil
+	}, WithName("billing"), WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+	eb.Subscribe("order.created", func(msg *EventMessage) { panic("audit crashed") }, WithName("audit"))
+
+	var final []*Failure
+	eb.OnFailure(func(f *Failure) {
+		mu.Lock()
+		defer mu.Unlock()
+		if f.Final {
+			final = append(final, f)
+		}
+	})
+	eb.Publish("order.created", order{Id: 1})
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	letters, err := eb.DeadLetters(ctx, DeadLetterFilter{Subscriber: "billing"})
+	if err != nil || len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter for billing, got %d, %v", len(letters), err)
+	}
+	letter := letters[0]
+	if letter.Event != "order.created" || letter.Attempts != 2 || letter.Error != "billing down" {
+		t.Errorf("unexpected dead letter: %+v", letter)
+	}
+	var panicErr *ErrHandlerPanic
+	if len(final) != 2 || !(errors.As(final[0].Err, &panicErr) || errors.As(final[1].Err, &panicErr)) {
+		t.Errorf("expected final failures of both handlers including the panic, got %+v", final)
+	}
+
+	if err := eb.ReplayDeadLetter(ctx, letter.ID); err == nil {
+		t.Error("replay should fail while billing is down")
+	}
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	if err := eb.ReplayDeadLetter(ctx, letter.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0] != letter.MessageID {
+		t.Errorf("replay should deliver the original message, got %v", received)
+	}
+	if _, err := eb.DeadLetter(ctx, letter.ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("replayed dead letter should be deleted, got %v", err)
+	}
+
+	if n, err := eb.PurgeDeadLetters(ctx, DeadLetterFilter{Event: "order.created"}); err != nil || n != 1 {
+		t.Errorf("expected to purge the audit dead letter, got %d, %v", n, err)
+	}
+}
+
+func TestSQLStore_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t, filepath.Join(t.TempDir(), "events.db"))
+	now := time.Now()
+	for i, event := range []string{"a", "b", "a"} {
+		store.AddDeadLetter(ctx, &DeadLetter{
+			ID:       string(rune('1' + i)),
+			Event:    event,
+			Data:     []byte(`{"Id":1}`),
+			FailedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	letters, err := store.DeadLetters(ctx, DeadLetterFilter{Event: "a"})
+	if err != nil || len(letters) != 2 || letters[0].ID != "1" || string(letters[0].Data) != `{"Id":1}` {
+		t.Fatalf("unexpected dead letters %+v, %v", letters, err)
+	}
+	if n, err := store.PurgeDeadLetters(ctx, DeadLetterFilter{Before: now.Add(1500 * time.Millisecond)}); err != nil || n != 2 {
+		t.Errorf("expected to purge 2, got %d, %v", n, err)
+	}
+	if n, _ := store.PurgeDeadLetters(ctx, DeadLetterFilter{}); n != 1 {
+		t.Errorf("expected to purge the remaining dead letter, got %d", n)
+	}
+}
+
+func TestEventBus_RetryReleasesWorker(t *testing.T) {
+	eb := NewEventBus()
+	eb.UseDeadLetters(NewMemoryDeadLetters())
+	handled := make(chan int, 2)
+	eb.SubscribeE("evt", func(msg *EventMessage) error {
+		var id int
+		msg.Unmarshal(&id)
+		if id == 1 {
+			return errors.New("unavailable")
+		}
+		handled <- id
+		return nil
+	}, WithName("worker"), WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Hour}), WithPool(PoolConfig{Workers: 1}))
+
+	// 第一条消息等待重试时唯一的 worker 仍可以处理后续消息
+	eb.Publish("evt", 1)
+	eb.Publish("evt", 2)
+	select {
+	case id := <-handled:
+		if id != 2 {
+			t.Errorf("unexpected message %d", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("retry backoff should not hold the worker")
+	}
+
+	// 停机时取消等待中的重试，消息写入死信
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := eb.Drain(ctx); err == nil {
+		t.Error("drain should time out while a retry is waiting")
+	}
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	letters, _ := eb.DeadLetters(context.Background(), DeadLetterFilter{Subscriber: "worker"})
+	if len(letters) != 1 || letters[0].Attempts != 1 {
+		t.Errorf("expected the canceled retry to be dead-lettered after 1 attempt, got %+v", letters)
+	}
+}
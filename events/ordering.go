@@ -0,0 +1,67 @@
+package events
+
+/**
+
+// 同一订单的消息按发布顺序逐条交给每个订阅者，不同订单之间仍并行处理
+eb.Publish("order.updated", order, events.WithPartitionKey(order.Id))
+
+// 使用序号检测丢失的消息（如工作池丢弃了消息），长时间未发布的分区键序号会从 1 重新开始
+last := map[string]uint64{}
+eb.Subscribe("order.updated", func(msg *events.EventMessage) {
+	if prev := last[msg.Key]; prev != 0 && msg.Seq > prev+1 {
+		lib.Log.Warnf("order %s missed %d updates", msg.Key, msg.Seq-prev-1)
+	}
+	last[msg.Key] = msg.Seq
+})
+
+*/
+
+// PublishOption 发布选项
+type PublishOption func(*EventMessage)
+
+// WithPartitionKey 设置分区键，同一事件总线上分区键相同的消息按发布顺序逐条交给每个订阅者，
+// 分区键不同的消息并行处理。并发发布带分区键的消息时，以入队的先后为顺序
+func WithPartitionKey(key string) PublishOption {
+	return func(msg *EventMessage) { msg.Key = key }
+}
+
+// partitionKey 工作池中需要串行执行的投递，owner 为订阅
+type partitionKey struct {
+	owner any
+	key   string
+}
+
+// defaultSequenceLimit 记录序号的事件与分区键数量上限
+const defaultSequenceLimit = 100000
+
+// seqEntry 一个事件与分区键的最新序号
+type seqEntry struct {
+	key string
+	seq uint64
+}
+
+// sequence 为消息分配同一事件与分区键内从 1 开始递增的序号，回复消息不分配。
+// 只记录最近发布的 seqLimit 个事件与分区键，被淘汰的分区键再次发布时序号从 1 重新开始
+func (eb *EventBus) sequence(msg *EventMessage) {
+	if msg.CorrelationID != "" {
+		return
+	}
+	eb.seqLock.Lock()
+	defer eb.seqLock.Unlock()
+	k := msg.Event + "\x00" + msg.Key
+	elem, ok := eb.seqs[k]
+	if ok {
+		eb.seqOrder.MoveToFront(elem)
+	} else {
+		elem = eb.seqOrder.PushFront(&seqEntry{key: k})
+		eb.seqs[k] = elem
+		for eb.seqOrder.Len() > eb.seqLimit {
+			oldest := eb.seqOrder.Back()
+			eb.seqOrder.Remove(oldest)
+			delete(eb.seqs, oldest.Value.(*seqEntry).key)
+		}
+	}
+	entry := elem.Value.(*seqEntry)
+	entry.seq++
+	msg.Seq = entry.seq
+}
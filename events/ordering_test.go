@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventBus_PartitionKeyOrdering(t *testing.T) {
+	eb := NewEventBus()
+	eb.SetPool(PoolConfig{Workers: 8, QueueSize: 1000})
+	var mu sync.Mutex
+	received := map[string][]uint64{}
+	var running, peak int32
+	eb.Subscribe("account.updated", func(msg *EventMessage) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		mu.Lock()
+		received[msg.Key] = append(received[msg.Key], msg.Seq)
+		mu.Unlock()
+	})
+	for i := 0; i < 60; i++ {
+		eb.Publish("account.updated", i, WithPartitionKey(fmt.Sprintf("acc-%d", i%4)))
+	}
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for key, seqs := range received {
+		if len(seqs) != 15 {
+			t.Errorf("%s: expected 15 messages, got %d", key, len(seqs))
+		}
+		for i, seq := range seqs {
+			if seq != uint64(i+1) {
+				t.Fatalf("%s: messages out of order: %v", key, seqs)
+			}
+		}
+	}
+	if peak < 2 {
+		t.Error("different keys should be handled in parallel")
+	}
+	if peak > 4 {
+		t.Errorf("messages with the same key should not run concurrently, peak %d", peak)
+	}
+}
+
+func TestEventBus_SequencePerEventAndKey(t *testing.T) {
+	eb := NewEventBus()
+	var seqs []string
+	eb.SubscribePattern("evt.*", func(msg *EventMessage) {
+		seqs = append(seqs, fmt.Sprintf("%s/%s/%d", msg.Event, msg.Key, msg.Seq))
+	})
+	eb.SyncPublish("evt.a", nil)
+	eb.SyncPublish("evt.a", nil, WithPartitionKey("k"))
+	eb.SyncPublish("evt.b", nil, WithPartitionKey("k"))
+	eb.SyncPublish("evt.a", nil)
+	expected := "[evt.a//1 evt.a/k/1 evt.b/k/1 evt.a//2]"
+	if fmt.Sprint(seqs) != expected {
+		t.Errorf("expected %s, got %v", expected, seqs)
+	}
+}
+
+func TestEventBus_SequenceLimit(t *testing.T) {
+	eb := NewEventBus()
+	eb.seqLimit = 2
+	var seqs []string
+	eb.Subscribe("evt", func(msg *EventMessage) {
+		seqs = append(seqs, fmt.Sprintf("%s/%d", msg.Key, msg.Seq))
+	})
+	for _, key := range []string{"a", "b", "a", "c", "a", "b"} {
+		eb.SyncPublish("evt", nil, WithPartitionKey(key))
+	}
+	// 发布 c 时淘汰最久未发布的 b，b 的序号重新开始
+	expected := "[a/1 b/1 a/2 c/1 a/3 b/1]"
+	if fmt.Sprint(seqs) != expected {
+		t.Errorf("expected %s, got %v", expected, seqs)
+	}
+	if len(eb.seqs) != 2 {
+		t.Errorf("expected 2 tracked keys, got %d", len(eb.seqs))
+	}
+}
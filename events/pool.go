@@ -0,0 +1,366 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 全局工作池：最多 32 个处理器并发执行，队列满时丢弃最早的消息
+eb.SetPool(events.PoolConfig{Workers: 32, QueueSize: 1000, Overflow: events.OverflowDropOldest})
+
+// 慢处理器使用独立的工作池，队列满时 Publish 返回 *events.ErrQueueFull
+eb.Subscribe("report.requested", buildReport, events.WithPool(events.PoolConfig{Workers: 2, QueueSize: 50, Overflow: events.OverflowError}))
+if err := eb.Publish("report.requested", req); err != nil {
+	return err
+}
+
+// 队列深度与处理延迟
+for _, p := range eb.Stats().Pools {
+	fmt.Println(p.Name, p.QueueDepth, p.AvgWait, p.AvgLatency)
+}
+
+*/
+
+// OverflowPolicy 队列已满时的处理方式
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞 Publish 直到队列有空位（默认）
+	OverflowDropOldest                       // 丢弃队列中最早的消息
+	OverflowDropNewest                       // 丢弃新发布的消息
+	OverflowError                            // 不入队，Publish 返回 *ErrQueueFull
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowError:
+		return "error"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+// PoolConfig 工作池配置，异步投递的处理器在工作池中执行
+type PoolConfig struct {
+	Workers   int            // 最多同时执行的处理器数量，默认为 CPU 数
+	QueueSize int            // 等待执行的消息数量上限，默认 1024
+	Overflow  OverflowPolicy // 队列已满时的处理方式
+}
+
+// DefaultPoolConfig 事件总线默认的全局工作池配置
+var DefaultPoolConfig = PoolConfig{Workers: 256, QueueSize: 10000, Overflow: OverflowBlock}
+
+// WithPool 订阅使用独立的工作池，不与其他订阅共享全局工作池
+func WithPool(config PoolConfig) SubscribeOption {
+	return func(s *subscriber) { s.poolConfig = &config }
+}
+
+// SetPool 替换全局工作池，已入队的消息仍由原工作池执行
+func (eb *EventBus) SetPool(config PoolConfig) {
+	pool := newWorkerPool("global", config, eb.done)
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	eb.pool = pool
+}
+
+// ErrQueueFull 工作池队列已满，消息未投递
+type ErrQueueFull struct {
+	Pool  string
+	Event string
+}
+
+func (e *ErrQueueFull) Error() string {
+	return fmt.Sprintf("event queue of pool '%s' is full, event %s rejected", e.Pool, e.Event)
+}
+
+// PoolStats 工作池统计，等待时间为入队到开始执行，延迟为处理器执行时间（含重试）
+type PoolStats struct {
+	Name       string         `json:"name"` // "global" 或订阅者名称
+	Workers    int            `json:"workers"`
+	Busy       int            `json:"busy"` // 正在执行的处理器数量
+	QueueDepth int            `json:"queue_depth"`
+	QueueSize  int            `json:"queue_size"`
+	Overflow   OverflowPolicy `json:"overflow"`
+	Retrying   int            `json:"retrying"` // 等待重试的投递，不占用 worker 与队列
+	Processed  int64          `json:"processed"`
+	Dropped    int64          `json:"dropped"`  // 队列已满时丢弃的消息
+	Rejected   int64          `json:"rejected"` // 队列已满时返回错误的消息
+	AvgWait    time.Duration  `json:"avg_wait"`
+	MaxWait    time.Duration  `json:"max_wait"`
+	AvgLatency time.Duration  `json:"avg_latency"`
+	MaxLatency time.Duration  `json:"max_latency"`
+}
+
+// job 一次待执行的投递
+type job struct {
+	event      string
+	id         string
+	subscriber string
+	partition  partitionKey // 分区键不为空时同一订阅与分区键的投递串行执行
+	// run 执行投递，返回大于 0 时在该时间后重新执行，等待期间不占用 worker，分区仍保持占用；
+	// canceled 为 true 时等待已被取消，应放弃重试
+	run      func(canceled bool) time.Duration
+	drop     func() // 被丢弃时调用，可以为 nil
+	enqueued time.Time
+	canceled bool
+	// ctx 不为 nil 时，溢出策略为 OverflowBlock 的等待在 ctx 结束时放弃，按队列已满丢弃并返回 ctx 的错误
+	ctx context.Context
+}
+
+// workerPool 有界队列的工作池，worker 按需启动，队列为空时退出。
+// 取出的投递所在分区正在执行时暂存到分区，由执行该分区的 worker 依次执行，暂存的投递计入队列长度。
+// 需要重试的投递在等待期间释放 worker，等待结束后优先执行，不受队列长度限制
+type workerPool struct {
+	name    string
+	config  PoolConfig
+	done    func() // 每个入队的消息执行完或被丢弃后调用
+	mu      sync.Mutex
+	notFull *sync.Cond
+	queue   []*job
+	active  map[partitionKey][]*job // 正在执行的分区与其暂存的投递
+	parked  int
+	retries []*job               // 等待结束的重试，所在分区仍由其占用
+	waiting map[*job]*time.Timer // 等待重试的投递
+	running int                  // 已启动的 worker
+	busy    int
+
+	processed, dropped, rejected int64
+	waitTotal, waitMax           time.Duration
+	runTotal, runMax             time.Duration
+}
+
+func newWorkerPool(name string, config PoolConfig, done func()) *workerPool {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	p := &workerPool{name: name, config: config, done: done, active: make(map[partitionKey][]*job), waiting: make(map[*job]*time.Timer)}
+	p.notFull = sync.NewCond(&p.mu)
+	return p
+}
+
+// submit 将消息加入队列，按溢出策略处理已满的队列
+func (p *workerPool) submit(j *job) error {
+	j.enqueued = time.Now()
+	p.mu.Lock()
+	var dropped *job
+	for len(p.queue)+p.parked >= p.config.QueueSize && dropped == nil {
+		switch p.config.Overflow {
+		case OverflowDropOldest:
+			if len(p.queue) == 0 {
+				dropped = j // 只有暂存的投递，丢弃最早的会打乱分区顺序
+				break
+			}
+			dropped = p.queue[0]
+			p.queue[0] = nil
+			p.queue = p.queue[1:]
+		case OverflowDropNewest:
+			dropped = j
+		case OverflowError:
+			p.rejected++
+			p.mu.Unlock()
+			p.discard(j)
+			return &ErrQueueFull{Pool: p.name, Event: j.event}
+		default:
+			if j.ctx == nil {
+				p.notFull.Wait()
+				continue
+			}
+			if err := j.ctx.Err(); err != nil {
+				p.rejected++
+				p.mu.Unlock()
+				p.discard(j)
+				return err
+			}
+			stop := context.AfterFunc(j.ctx, func() {
+				p.mu.Lock()
+				p.notFull.Broadcast()
+				p.mu.Unlock()
+			})
+			p.notFull.Wait()
+			stop()
+		}
+	}
+	if dropped != nil {
+		p.dropped++
+	}
+	if dropped != j {
+		p.queue = append(p.queue, j)
+		p.spawn()
+	}
+	p.mu.Unlock()
+	if dropped != nil {
+		lib.Log.Warnf("[EventBus] queue of pool '%s' is full, dropped event %s (%s) for '%s'", p.name, dropped.event, dropped.id, dropped.subscriber)
+		p.discard(dropped)
+	}
+	return nil
+}
+
+// spawn 未达到 worker 上限时启动一个 worker；调用时持有 p.mu
+func (p *workerPool) spawn() {
+	if p.running < p.config.Workers {
+		p.running++
+		go p.work()
+	}
+}
+
+func (p *workerPool) discard(j *job) {
+	if j.drop != nil {
+		j.drop()
+	}
+	p.done()
+}
+
+func (p *workerPool) work() {
+	for {
+		p.mu.Lock()
+		j := p.next()
+		if j == nil {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		p.busy++
+		p.mu.Unlock()
+
+		for j != nil {
+			start := time.Now()
+			retryAfter := j.run(j.canceled)
+			elapsed := time.Since(start)
+			wait := start.Sub(j.enqueued)
+			if retryAfter <= 0 {
+				p.done()
+			}
+
+			p.mu.Lock()
+			p.waitTotal += wait
+			p.waitMax = max(p.waitMax, wait)
+			p.runTotal += elapsed
+			p.runMax = max(p.runMax, elapsed)
+			if retryAfter > 0 {
+				p.schedule(j, retryAfter)
+				j = nil
+			} else {
+				p.processed++
+				j = p.finish(j)
+			}
+			if j == nil {
+				p.busy--
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// schedule 在 after 后重新执行投递；调用时持有 p.mu
+func (p *workerPool) schedule(j *job, after time.Duration) {
+	p.waiting[j] = time.AfterFunc(after, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.waiting[j]; ok {
+			delete(p.waiting, j)
+			p.ready(j)
+		}
+	})
+}
+
+// ready 等待结束的重试加入待执行列表；调用时持有 p.mu
+func (p *workerPool) ready(j *job) {
+	j.enqueued = time.Now()
+	p.retries = append(p.retries, j)
+	p.spawn()
+}
+
+// cancelRetries 取消所有等待中的重试，投递立即重新执行并放弃重试
+func (p *workerPool) cancelRetries() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for j, timer := range p.waiting {
+		timer.Stop()
+		delete(p.waiting, j)
+		j.canceled = true
+		p.ready(j)
+	}
+}
+
+// next 取出下一个可以执行的投递，等待结束的重试优先，所在分区正在执行的投递暂存到分区；调用时持有 p.mu
+func (p *workerPool) next() *job {
+	if len(p.retries) > 0 {
+		j := p.retries[0]
+		p.retries[0] = nil
+		p.retries = p.retries[1:]
+		return j
+	}
+	for len(p.queue) > 0 {
+		j := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		if j.partition.key == "" {
+			p.notFull.Signal()
+			return j
+		}
+		if parked, ok := p.active[j.partition]; ok {
+			p.active[j.partition] = append(parked, j)
+			p.parked++
+			continue
+		}
+		p.active[j.partition] = nil
+		p.notFull.Signal()
+		return j
+	}
+	return nil
+}
+
+// finish 结束一个投递，返回同一分区暂存的下一个投递；调用时持有 p.mu
+func (p *workerPool) finish(j *job) *job {
+	if j.partition.key == "" {
+		return nil
+	}
+	parked := p.active[j.partition]
+	if len(parked) == 0 {
+		delete(p.active, j.partition)
+		return nil
+	}
+	p.active[j.partition] = parked[1:]
+	p.parked--
+	p.notFull.Signal()
+	return parked[0]
+}
+
+func (p *workerPool) stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := PoolStats{
+		Name:       p.name,
+		Workers:    p.config.Workers,
+		Busy:       p.busy,
+		QueueDepth: len(p.queue) + p.parked,
+		QueueSize:  p.config.QueueSize,
+		Overflow:   p.config.Overflow,
+		Retrying:   len(p.waiting) + len(p.retries),
+		Processed:  p.processed,
+		Dropped:    p.dropped,
+		Rejected:   p.rejected,
+		MaxWait:    p.waitMax,
+		MaxLatency: p.runMax,
+	}
+	if p.processed > 0 {
+		s.AvgWait = p.waitTotal / time.Duration(p.processed)
+		s.AvgLatency = p.runTotal / time.Duration(p.processed)
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventBus_PoolLimitsConcurrency(t *testing.T) {
+	eb := NewEventBus()
+	eb.SetPool(PoolConfig{Workers: 2, QueueSize: 100})
+	var running, peak int32
+	eb.Subscribe("evt", func(msg *EventMessage) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+	for i := 0; i < 20; i++ {
+		eb.Publish("evt", i)
+	}
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if peak != 2 {
+		t.Errorf("expected at most 2 concurrent handlers, got %d", peak)
+	}
+	if s := eb.Stats().Pools[0]; s.Processed != 20 || s.QueueDepth != 0 || s.AvgLatency <= 0 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+// blockedPool 订阅一个使用独立工作池的处理器，并让唯一的 worker 阻塞在第一条消息上
+func blockedPool(t *testing.T, overflow OverflowPolicy) (eb *EventBus, received *[]int, release func()) {
+	eb = NewEventBus()
+	var mu sync.Mutex
+	received = &[]int{}
+	started := make(chan struct{}, 1)
+	gate := make(chan struct{})
+	eb.Subscribe("evt", func(msg *EventMessage) {
+		if msg.Data.(int) == 0 {
+			started <- struct{}{}
+			<-gate
+		}
+		mu.Lock()
+		*received = append(*received, msg.Data.(int))
+		mu.Unlock()
+	}, WithName("slow"), WithPool(PoolConfig{Workers: 1, QueueSize: 2, Overflow: overflow}))
+	eb.Publish("evt", 0)
+	<-started
+	return eb, received, func() {
+		close(gate)
+		if err := eb.Drain(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEventBus_PoolOverflow(t *testing.T) {
+	tests := []struct {
+		overflow OverflowPolicy
+		expected []int
+	}{
+		{OverflowDropOldest, []int{0, 3, 4}},
+		{OverflowDropNewest, []int{0, 1, 2}},
+		{OverflowError, []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overflow.String(), func(t *testing.T) {
+			eb, received, release := blockedPool(t, tt.overflow)
+			var errs []error
+			for i := 1; i <= 4; i++ {
+				if err := eb.Publish("evt", i); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			stats := eb.Stats()
+			if len(stats.Pools) != 2 || stats.Pools[1].Name != "slow" || stats.Pools[1].QueueDepth != 2 {
+				t.Errorf("unexpected pool stats: %+v", stats.Pools)
+			}
+			release()
+			if len(*received) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, *received)
+			}
+			for i := range tt.expected {
+				if (*received)[i] != tt.expected[i] {
+					t.Fatalf("expected %v, got %v", tt.expected, *received)
+				}
+			}
+			var queueFull *ErrQueueFull
+			if tt.overflow == OverflowError {
+				if len(errs) != 2 || !errors.As(errs[0], &queueFull) || queueFull.Pool != "slow" {
+					t.Errorf("expected 2 queue full errors, got %v", errs)
+				}
+				if eb.Stats().Pools[1].Rejected != 2 {
+					t.Errorf("expected 2 rejected, got %+v", eb.Stats().Pools[1])
+				}
+			} else if len(errs) != 0 || eb.Stats().Pools[1].Dropped != 2 {
+				t.Errorf("expected 2 dropped without errors, got %v, %+v", errs, eb.Stats().Pools[1])
+			}
+		})
+	}
+}
+
+func TestEventBus_PoolBlock(t *testing.T) {
+	eb, received, release := blockedPool(t, OverflowBlock)
+	eb.Publish("evt", 1)
+	eb.Publish("evt", 2)
+	published := make(chan struct{})
+	go func() {
+		eb.Publish("evt", 3)
+		close(published)
+	}()
+	select {
+	case <-published:
+		t.Fatal("publish should block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	<-published
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*received) != 4 {
+		t.Errorf("no message should be dropped, got %v", *received)
+	}
+}
@@ -0,0 +1,261 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 等待所有处理器完成
+results, err := eb.PublishAndWait(ctx, "order.created", order)
+if err != nil {
+	return err // ctx 结束时仍有处理器未完成
+}
+if err := results.Err(); err != nil {
+	return err // 处理器返回的错误
+}
+
+// 请求/回复：处理器使用 Reply 回复，发布者接收第一个回复
+eb.SubscribeE("price.quote", func(msg *events.EventMessage) error {
+	var req QuoteReq
+	msg.Unmarshal(&req)
+	return eb.Reply(msg, quote(req))
+})
+reply, err := eb.Request(ctx, "price.quote", QuoteReq{Sku: "A1"})
+var q Quote
+reply.Unmarshal(&q)
+
+// 接收所有处理器的回复
+replies, err := eb.RequestAll(ctx, "price.quote", QuoteReq{Sku: "A1"})
+
+*/
+
+// replyPrefix 回复地址的前缀，后接请求消息的 ID
+const replyPrefix = "_inbox."
+
+var (
+	// ErrNoResponders 请求的事件没有订阅者
+	ErrNoResponders = errors.New("no responders")
+	// ErrNoReply 所有处理器已结束但没有回复
+	ErrNoReply = errors.New("no reply")
+	// ErrNoReplyTo 消息不是请求，无法回复
+	ErrNoReplyTo = errors.New("message has no reply address")
+)
+
+// HandlerResult 一个处理器处理消息的结果
+type HandlerResult struct {
+	Subscriber string
+	Done       bool          // 处理器已结束或消息被丢弃
+	Err        error         // 处理失败（重试用尽后）的错误，消息被丢弃时为 *ErrQueueFull
+	Duration   time.Duration // 处理耗时，含重试
+}
+
+// HandlerResults PublishAndWait 的结果，按处理器的执行顺序排列
+type HandlerResults []HandlerResult
+
+// Err 合并所有处理器的错误，未结束的处理器不计入
+func (r HandlerResults) Err() error {
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Subscriber, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishAndWait 发布消息并等待所有处理器结束，返回每个处理器的结果，持久化保存失败时返回 *ErrPersist；
+// ctx 结束时返回已有的结果与 ctx 的错误，未结束的处理器 Done 为 false 并继续执行。
+// 等待队列空位时 ctx 结束同样返回，尚未入队的处理器按队列已满写入死信
+func (eb *EventBus) PublishAndWait(ctx context.Context, event string, data interface{}, opts ...PublishOption) (HandlerResults, error) {
+	w := newWaiter(ctx)
+	var persistErr *ErrPersist
+	if err := eb.publish(newMessage(event, data, opts), w); errors.As(err, &persistErr) {
+		return nil, err
+	}
+	w.seal()
+	if ctx.Err() != nil {
+		return w.snapshot(), ctx.Err()
+	}
+	select {
+	case <-w.done:
+		return w.snapshot(), nil
+	case <-ctx.Done():
+		return w.snapshot(), ctx.Err()
+	}
+}
+
+// Request 发布请求并返回第一个回复；没有订阅者时返回 ErrNoResponders，
+// 所有处理器结束仍没有回复时返回 ErrNoReply（包含处理器的错误）
+func (eb *EventBus) Request(ctx context.Context, event string, data interface{}, opts ...PublishOption) (*EventMessage, error) {
+	replies, err := eb.request(ctx, event, data, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// RequestAll 发布请求，等待所有处理器结束后返回收到的全部回复；
+// ctx 结束时返回已收到的回复与 ctx 的错误
+func (eb *EventBus) RequestAll(ctx context.Context, event string, data interface{}, opts ...PublishOption) ([]*EventMessage, error) {
+	return eb.request(ctx, event, data, opts, false)
+}
+
+func (eb *EventBus) request(ctx context.Context, event string, data interface{}, opts []PublishOption, first bool) ([]*EventMessage, error) {
+	msg := newMessage(event, data, opts)
+	msg.ReplyTo = replyPrefix + msg.ID
+	var mu sync.Mutex
+	var replies []*EventMessage
+	received := make(chan struct{}, 1)
+	eb.lock.Lock()
+	eb.inboxes[msg.ReplyTo] = func(reply *EventMessage) {
+		mu.Lock()
+		replies = append(replies, reply)
+		mu.Unlock()
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+	}
+	eb.lock.Unlock()
+	defer func() {
+		eb.lock.Lock()
+		delete(eb.inboxes, msg.ReplyTo)
+		eb.lock.Unlock()
+	}()
+
+	w := newWaiter(ctx)
+	var persistErr *ErrPersist
+	if err := eb.publish(msg, w); errors.As(err, &persistErr) {
+		return nil, fmt.Errorf("request %s: %w", event, err)
+	}
+	w.seal()
+	if w.len() == 0 {
+		return nil, fmt.Errorf("request %s: %w", event, ErrNoResponders)
+	}
+	collected := func() []*EventMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		if first && len(replies) > 1 {
+			return replies[:1]
+		}
+		return append([]*EventMessage(nil), replies...)
+	}
+	canceled := func() ([]*EventMessage, error) {
+		if first {
+			return nil, fmt.Errorf("request %s: %w", event, ctx.Err())
+		}
+		return collected(), ctx.Err()
+	}
+	if ctx.Err() != nil {
+		// 等待队列空位时 ctx 已结束
+		return canceled()
+	}
+	for {
+		select {
+		case <-received:
+			if first {
+				return collected(), nil
+			}
+		case <-w.done:
+			if res := collected(); len(res) > 0 {
+				return res, nil
+			}
+			if err := w.snapshot().Err(); err != nil {
+				return nil, fmt.Errorf("request %s: %w: %w", event, ErrNoReply, err)
+			}
+			return nil, fmt.Errorf("request %s: %w", event, ErrNoReply)
+		case <-ctx.Done():
+			return canceled()
+		}
+	}
+}
+
+// Reply 回复请求，回复消息的 CorrelationID 为请求消息的 ID，在当前 goroutine 中直接交给请求方，
+// 不经过订阅匹配、序号分配与持久化，模式订阅与事件联邦不会收到回复；请求已结束时回复被忽略
+func (eb *EventBus) Reply(req *EventMessage, data interface{}) error {
+	if req.ReplyTo == "" {
+		return fmt.Errorf("reply to event %s (%s): %w", req.Event, req.ID, ErrNoReplyTo)
+	}
+	eb.lock.RLock()
+	inbox := eb.inboxes[req.ReplyTo]
+	eb.lock.RUnlock()
+	if inbox == nil {
+		lib.Log.Debugf("[EventBus] request %s (%s) is finished, reply ignored", req.Event, req.ID)
+		return nil
+	}
+	reply := newMessage(req.ReplyTo, data, nil)
+	reply.CorrelationID = req.ID
+	inbox(reply)
+	return nil
+}
+
+// waiter 记录一次发布中每个处理器的结果，所有处理器结束后关闭 done；nil 时不记录
+type waiter struct {
+	ctx     context.Context // 调用方的 ctx，结束时放弃等待队列空位
+	mu      sync.Mutex
+	results HandlerResults
+	pending int
+	sealed  bool
+	done    chan struct{}
+}
+
+func newWaiter(ctx context.Context) *waiter {
+	return &waiter{ctx: ctx, done: make(chan struct{})}
+}
+
+// add 添加一个处理器，返回其结果的下标
+func (w *waiter) add(subscriber string) int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.results = append(w.results, HandlerResult{Subscriber: subscriber})
+	w.pending++
+	return len(w.results) - 1
+}
+
+func (w *waiter) finish(i int, err error, d time.Duration) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.results[i].Done {
+		return
+	}
+	w.results[i] = HandlerResult{Subscriber: w.results[i].Subscriber, Done: true, Err: err, Duration: d}
+	w.pending--
+	if w.sealed && w.pending == 0 {
+		close(w.done)
+	}
+}
+
+// seal 所有处理器已添加
+func (w *waiter) seal() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sealed = true
+	if w.pending == 0 {
+		close(w.done)
+	}
+}
+
+func (w *waiter) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.results)
+}
+
+func (w *waiter) snapshot() HandlerResults {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append(HandlerResults(nil), w.results...)
+}
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestEventBus_PublishAndWait(t *testing.T) {
+	eb := NewEventBus()
+	eb.Subscribe("evt", func(msg *EventMessage) { time.Sleep(5 * time.Millisecond) }, WithName("slow"))
+	eb.SubscribeE("evt", func(msg *EventMessage) error { return errors.New("boom") }, WithName("failing"))
+
+	results, err := eb.PublishAndWait(context.Background(), "evt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !results[0].Done || results[0].Err != nil || results[0].Duration < 5*time.Millisecond {
+		t.Errorf("unexpected result of slow handler: %+v", results)
+	}
+	if results[1].Subscriber != "failing" || results[1].Err == nil || results.Err() == nil {
+		t.Errorf("expected error of failing handler: %+v", results)
+	}
+
+	release := make(chan struct{})
+	eb.Subscribe("blocked", func(msg *EventMessage) { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	results, err = eb.PublishAndWait(ctx, "blocked", nil)
+	close(release)
+	if !errors.Is(err, context.DeadlineExceeded) || len(results) != 1 || results[0].Done {
+		t.Errorf("expected unfinished handler on timeout, got %+v, %v", results, err)
+	}
+}
+
+func TestEventBus_WaitHonorsContextWhenQueueFull(t *testing.T) {
+	eb := NewEventBus()
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	eb.Subscribe("busy", func(msg *EventMessage) {
+		entered <- struct{}{}
+		<-release
+	}, WithPool(PoolConfig{Workers: 1, QueueSize: 1}))
+	defer close(release)
+	eb.Publish("busy", nil)
+	<-entered
+	eb.Publish("busy", nil) // 队列已满，之后的发布阻塞
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 2)
+	go func() {
+		_, err := eb.PublishAndWait(ctx, "busy", nil)
+		done <- err
+	}()
+	go func() {
+		_, err := eb.Request(ctx, "busy", nil)
+		done <- err
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected context deadline, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("waiting for a queue slot should end with the context")
+		}
+	}
+}
+
+func TestEventBus_Request(t *testing.T) {
+	eb := NewEventBus()
+	for _, price := range []int{10, 12} {
+		eb.SubscribeE("price.quote", func(msg *EventMessage) error {
+			if price == 12 {
+				time.Sleep(5 * time.Millisecond)
+			}
+			return eb.Reply(msg, price)
+		})
+	}
+
+	reply, err := eb.Request(context.Background(), "price.quote", "A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Data != 10 || reply.CorrelationID == "" || reply.Seq != 0 {
+		t.Errorf("unexpected first reply: %+v", reply)
+	}
+
+	replies, err := eb.RequestAll(context.Background(), "price.quote", "A1")
+	if err != nil || len(replies) != 2 {
+		t.Fatalf("expected 2 replies, got %d, %v", len(replies), err)
+	}
+	prices := []int{replies[0].Data.(int), replies[1].Data.(int)}
+	sort.Ints(prices)
+	if prices[0] != 10 || prices[1] != 12 || replies[0].CorrelationID != replies[1].CorrelationID {
+		t.Errorf("unexpected replies: %v", prices)
+	}
+	if len(eb.inboxes) != 0 {
+		t.Error("reply inbox should be removed after the request")
+	}
+}
+
+func TestEventBus_ReplyBypassesSubscriptions(t *testing.T) {
+	eb := NewEventBus()
+	var seen []string
+	eb.SubscribePattern(">", func(msg *EventMessage) { seen = append(seen, msg.Event) })
+	eb.SubscribeE("price.quote", func(msg *EventMessage) error { return eb.Reply(msg, 10) })
+
+	reply, err := eb.Request(context.Background(), "price.quote", "A1")
+	if err != nil || reply.Data != 10 {
+		t.Fatalf("unexpected reply %+v, %v", reply, err)
+	}
+	eb.Drain(context.Background())
+	if len(seen) != 1 || seen[0] != "price.quote" {
+		t.Errorf("pattern subscribers should not receive replies, got %v", seen)
+	}
+}
+
+func TestEventBus_RequestErrors(t *testing.T) {
+	eb := NewEventBus()
+	if _, err := eb.Request(context.Background(), "nobody", nil); !errors.Is(err, ErrNoResponders) {
+		t.Errorf("expected ErrNoResponders, got %v", err)
+	}
+
+	handlerErr := errors.New("out of stock")
+	eb.SubscribeE("silent", func(msg *EventMessage) error { return handlerErr })
+	_, err := eb.Request(context.Background(), "silent", nil)
+	if !errors.Is(err, ErrNoReply) || !errors.Is(err, handlerErr) {
+		t.Errorf("expected ErrNoReply with the handler error, got %v", err)
+	}
+
+	if err := eb.Reply(&EventMessage{Event: "evt"}, nil); !errors.Is(err, ErrNoReplyTo) {
+		t.Errorf("expected ErrNoReplyTo, got %v", err)
+	}
+}
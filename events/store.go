@@ -0,0 +1,305 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store 持久化订阅使用的事件存储，记录消息与每个持久化订阅者的投递状态
+type Store interface {
+	// SaveSubscriber 记录持久化订阅者及其订阅的事件或模式
+	SaveSubscriber(ctx context.Context, name, pattern string) error
+	// DeleteSubscriber 删除持久化订阅者及其未确认的消息
+	DeleteSubscriber(ctx context.Context, name string) error
+	// Subscribers 返回所有持久化订阅者，键为名称，值为事件或模式
+	Subscribers(ctx context.Context) (map[string]string, error)
+	// Append 保存消息并为每个订阅者记录待确认的投递，delivered 中的订阅者已开始首次投递
+	Append(ctx context.Context, msg *EventMessage, subscribers []string, delivered map[string]bool) error
+	// Pending 按发布顺序返回订阅者未确认的消息，并将其投递次数加一，Data 为 json.RawMessage
+	Pending(ctx context.Context, subscriber string, limit int) ([]*EventMessage, error)
+	// Ack 确认订阅者已处理消息，所有订阅者确认后删除消息
+	Ack(ctx context.Context, id, subscriber string) error
+}
+
+// SQLStore 基于 gorm 的事件存储，通常使用 db.DbService 的 SQLite 连接。
+// 写操作串行执行，避免 SQLite 的写锁冲突
+type SQLStore struct {
+	db *gorm.DB
+	mu sync.Mutex
+}
+
+type storedEvent struct {
+	Seq       int64     `gorm:"primaryKey;autoIncrement"` // 发布顺序
+	ID        string    `gorm:"uniqueIndex;size:64"`
+	Event     string    `gorm:"size:255;index"`
+	Data      []byte    // JSON 编码的消息数据
+	Timestamp time.Time // 发布时间
+	Key       string    `gorm:"size:255"` // 分区键
+	KeySeq    uint64    // EventMessage.Seq
+	Origin    string    `gorm:"size:255"` // 来源节点 ID，本节点发布的消息为空
+}
+
+func (storedEvent) TableName() string { return "gloop_events" }
+
+type storedDelivery struct {
+	MessageID  string `gorm:"primaryKey;size:64"`
+	Subscriber string `gorm:"primaryKey;size:255;index"`
+	Seq        int64  `gorm:"index"`
+	Attempts   int
+}
+
+func (storedDelivery) TableName() string { return "gloop_event_deliveries" }
+
+type storedSubscriber struct {
+	Name    string `gorm:"primaryKey;size:255"`
+	Pattern string `gorm:"size:255"`
+}
+
+func (storedSubscriber) TableName() string { return "gloop_event_subscribers" }
+
+type storedDeadLetter struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	MessageID  string `gorm:"size:64;index"`
+	Event      string `gorm:"size:255;index"`
+	Subscriber string `gorm:"size:255;index"`
+	Data       []byte
+	Error      string
+	Attempts   int
+	Timestamp  time.Time
+	Key        string `gorm:"size:255"`
+	KeySeq     uint64
+	FailedAt   time.Time `gorm:"index"`
+}
+
+func (storedDeadLetter) TableName() string { return "gloop_event_dead_letters" }
+
+// NewSQLStore 创建事件存储并迁移数据表，同时实现 Store 与 DeadLetterStore
+func NewSQLStore(db *gorm.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil, cannot create event store")
+	}
+	if err := db.AutoMigrate(&storedEvent{}, &storedDelivery{}, &storedSubscriber{}, &storedDeadLetter{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate event store tables: %w", err)
+	}
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) SaveSubscriber(ctx context.Context, name, pattern string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&storedSubscriber{Name: name, Pattern: pattern}).Error
+}
+
+func (s *SQLStore) DeleteSubscriber(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&storedSubscriber{}, "name = ?", name).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&storedDelivery{}, "subscriber = ?", name).Error; err != nil {
+			return err
+		}
+		return deleteAcked(tx)
+	})
+}
+
+func (s *SQLStore) Subscribers(ctx context.Context) (map[string]string, error) {
+	var rows []storedSubscriber
+	if err := s.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	subscribers := make(map[string]string, len(rows))
+	for _, row := range rows {
+		subscribers[row.Name] = row.Pattern
+	}
+	return subscribers, nil
+}
+
+func (s *SQLStore) Append(ctx context.Context, msg *EventMessage, subscribers []string, delivered map[string]bool) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("encode event data: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event := &storedEvent{ID: msg.ID, Event: msg.Event, Data: data, Timestamp: msg.Timestamp, Key: msg.Key, KeySeq: msg.Seq, Origin: msg.Origin}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		seq := event.Seq
+		deliveries := make([]storedDelivery, 0, len(subscribers))
+		for _, name := range subscribers {
+			delivery := storedDelivery{MessageID: msg.ID, Subscriber: name, Seq: seq}
+			if delivered[name] {
+				delivery.Attempts = 1
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return tx.Create(&deliveries).Error
+	})
+}
+
+func (s *SQLStore) Pending(ctx context.Context, subscriber string, limit int) ([]*EventMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []*EventMessage
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			ID        string
+			Event     string
+			Data      []byte
+			Timestamp time.Time
+			Key       string
+			KeySeq    uint64
+			Origin    string
+			Attempts  int
+		}
+		query := tx.Table("gloop_event_deliveries AS d").
+			Select("e.id, e.event, e.data, e.timestamp, e.key, e.key_seq, e.origin, d.attempts").
+			Joins("JOIN gloop_events AS e ON e.id = d.message_id").
+			Where("d.subscriber = ?", subscriber).
+			Order("d.seq")
+		if limit > 0 {
+			query = query.Limit(limit)
+		}
+		if err := query.Scan(&rows).Error; err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+			messages = append(messages, &EventMessage{
+				ID:        row.ID,
+				Event:     row.Event,
+				Timestamp: row.Timestamp,
+				Data:      json.RawMessage(row.Data),
+				Attempt:   row.Attempts + 1,
+				Key:       row.Key,
+				Seq:       row.KeySeq,
+				Origin:    row.Origin,
+			})
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&storedDelivery{}).
+			Where("subscriber = ? AND message_id IN ?", subscriber, ids).
+			Update("attempts", gorm.Expr("attempts + 1")).Error
+	})
+	return messages, err
+}
+
+func (s *SQLStore) Ack(ctx context.Context, id, subscriber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&storedDelivery{}, "message_id = ? AND subscriber = ?", id, subscriber).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND NOT EXISTS (SELECT 1 FROM gloop_event_deliveries WHERE message_id = ?)", id, id).
+			Delete(&storedEvent{}).Error
+	})
+}
+
+// deleteAcked 删除所有订阅者都已确认的消息
+func deleteAcked(tx *gorm.DB) error {
+	return tx.Where("NOT EXISTS (SELECT 1 FROM gloop_event_deliveries WHERE message_id = gloop_events.id)").
+		Delete(&storedEvent{}).Error
+}
+
+func (s *SQLStore) AddDeadLetter(ctx context.Context, letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.WithContext(ctx).Create(&storedDeadLetter{
+		ID:         letter.ID,
+		MessageID:  letter.MessageID,
+		Event:      letter.Event,
+		Subscriber: letter.Subscriber,
+		Data:       letter.Data,
+		Error:      letter.Error,
+		Attempts:   letter.Attempts,
+		Timestamp:  letter.Timestamp,
+		Key:        letter.Key,
+		KeySeq:     letter.Seq,
+		FailedAt:   letter.FailedAt,
+	}).Error
+}
+
+func (s *SQLStore) DeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetter, error) {
+	var rows []storedDeadLetter
+	query := deadLetterQuery(s.db.WithContext(ctx), filter).Order("failed_at")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	letters := make([]*DeadLetter, 0, len(rows))
+	for _, row := range rows {
+		letters = append(letters, row.deadLetter())
+	}
+	return letters, nil
+}
+
+func (s *SQLStore) DeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	var rows []storedDeadLetter
+	if err := s.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrDeadLetterNotFound
+	}
+	return rows[0].deadLetter(), nil
+}
+
+func (s *SQLStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.WithContext(ctx).Delete(&storedDeadLetter{}, "id = ?", id).Error
+}
+
+func (s *SQLStore) PurgeDeadLetters(ctx context.Context, filter DeadLetterFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := deadLetterQuery(s.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}), filter).
+		Delete(&storedDeadLetter{})
+	return int(result.RowsAffected), result.Error
+}
+
+func deadLetterQuery(db *gorm.DB, filter DeadLetterFilter) *gorm.DB {
+	if filter.Event != "" {
+		db = db.Where("event = ?", filter.Event)
+	}
+	if filter.Subscriber != "" {
+		db = db.Where("subscriber = ?", filter.Subscriber)
+	}
+	if !filter.Before.IsZero() {
+		db = db.Where("failed_at < ?", filter.Before)
+	}
+	return db
+}
+
+func (row storedDeadLetter) deadLetter() *DeadLetter {
+	return &DeadLetter{
+		ID:         row.ID,
+		MessageID:  row.MessageID,
+		Event:      row.Event,
+		Subscriber: row.Subscriber,
+		Data:       json.RawMessage(row.Data),
+		Error:      row.Error,
+		Attempts:   row.Attempts,
+		Timestamp:  row.Timestamp,
+		Key:        row.Key,
+		Seq:        row.KeySeq,
+		FailedAt:   row.FailedAt,
+	}
+}
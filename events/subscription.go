@@ -0,0 +1,193 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 订阅返回句柄，通过句柄取消订阅
+sub := eb.Subscribe("order.created", onOrder)
+defer sub.Unsubscribe()
+
+// 只处理大额订单，优先于其他订阅执行，最多处理 10 条，1 小时后自动取消
+eb.Subscribe("order.created", onBigOrder,
+	events.WithFilter(func(msg *events.EventMessage) bool { return msg.Data.(Order).Amount > 1000 }),
+	events.WithPriority(10),
+	events.WithMaxDeliveries(10),
+	events.WithExpiry(time.Hour),
+)
+
+// 订阅组：组件销毁时一起取消
+group := events.NewSubscriptionGroup()
+eb.Subscribe("user.created", onUser, events.InGroup(group))
+eb.SubscribePattern("order.>", onOrder, events.InGroup(group))
+group.Unsubscribe()
+
+*/
+
+// Subscription 订阅句柄，用于取消订阅与查看投递次数
+type Subscription struct {
+	bus *EventBus
+	sub *subscriber
+}
+
+// Unsubscribe 取消订阅，已开始执行与已入队的投递不受影响，重复调用无效果
+func (s *Subscription) Unsubscribe() {
+	if s == nil {
+		return
+	}
+	s.bus.unsubscribe(s.sub)
+}
+
+// Active 订阅未取消、未过期且投递次数未用尽
+func (s *Subscription) Active() bool {
+	if s == nil || s.sub.removed.Load() {
+		return false
+	}
+	return s.sub.expiresAt.IsZero() || time.Now().Before(s.sub.expiresAt)
+}
+
+// Name 订阅者名称
+func (s *Subscription) Name() string { return s.sub.name }
+
+// Topic 订阅的事件名称或模式
+func (s *Subscription) Topic() string {
+	if s.sub.pattern != "" {
+		return s.sub.pattern
+	}
+	return s.sub.event
+}
+
+// Deliveries 订阅已接收的消息数量，重试不重复计数
+func (s *Subscription) Deliveries() int {
+	return int(atomic.LoadInt64(&s.sub.deliveries))
+}
+
+// WithFilter 只投递 filter 返回 true 的消息，filter 在发布消息的 goroutine 中调用
+func WithFilter(filter func(msg *EventMessage) bool) SubscribeOption {
+	return func(s *subscriber) { s.filter = filter }
+}
+
+// WithPriority 设置优先级，同一事件的订阅按优先级从高到低执行（异步投递时为入队顺序），
+// 优先级相同时按订阅顺序，默认为 0
+func WithPriority(priority int) SubscribeOption {
+	return func(s *subscriber) { s.priority = priority }
+}
+
+// WithMaxDeliveries 接收 n 条消息后自动取消订阅
+func WithMaxDeliveries(n int) SubscribeOption {
+	return func(s *subscriber) { s.maxDeliveries = int64(n) }
+}
+
+// WithExpiry 订阅 d 之后自动取消
+func WithExpiry(d time.Duration) SubscribeOption {
+	return func(s *subscriber) { s.expiresAt = time.Now().Add(d) }
+}
+
+// InGroup 将订阅加入订阅组
+func InGroup(group *SubscriptionGroup) SubscribeOption {
+	return func(s *subscriber) { s.group = group }
+}
+
+// SubscriptionGroup 订阅组，用于一起取消一个组件的所有订阅，取消后可以继续加入新的订阅
+type SubscriptionGroup struct {
+	mu   sync.Mutex
+	subs []*Subscription
+}
+
+// NewSubscriptionGroup 创建订阅组
+func NewSubscriptionGroup() *SubscriptionGroup {
+	return &SubscriptionGroup{}
+}
+
+// Add 将已有的订阅加入订阅组
+func (g *SubscriptionGroup) Add(subs ...*Subscription) {
+	for _, s := range subs {
+		if s == nil || s.sub.removed.Load() {
+			continue
+		}
+		g.mu.Lock()
+		g.subs = append(g.subs, s)
+		g.mu.Unlock()
+	}
+}
+
+// Len 订阅组中有效的订阅数量
+func (g *SubscriptionGroup) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.subs)
+}
+
+// Unsubscribe 取消订阅组中的所有订阅
+func (g *SubscriptionGroup) Unsubscribe() {
+	g.mu.Lock()
+	subs := g.subs
+	g.subs = nil
+	g.mu.Unlock()
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
+
+func (g *SubscriptionGroup) remove(sub *subscriber) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, s := range g.subs {
+		if s.sub == sub {
+			g.subs = append(g.subs[:i:i], g.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// unsubscribe 移除订阅，重复调用无效果
+func (eb *EventBus) unsubscribe(sub *subscriber) {
+	if !sub.removed.CompareAndSwap(false, true) {
+		return
+	}
+	eb.remove(sub.event, sub.pattern, func(s *subscriber) bool { return s == sub })
+	if sub.group != nil {
+		sub.group.remove(sub)
+	}
+}
+
+// accept 判断订阅是否接收消息并计入投递次数，订阅过期或投递次数用尽时取消订阅
+func (eb *EventBus) accept(sub *subscriber, msg *EventMessage) bool {
+	if sub.removed.Load() {
+		return false
+	}
+	if !sub.expiresAt.IsZero() && !time.Now().Before(sub.expiresAt) {
+		eb.unsubscribe(sub)
+		return false
+	}
+	if sub.filter != nil && !applyFilter(sub, msg) {
+		return false
+	}
+	n := atomic.AddInt64(&sub.deliveries, 1)
+	if sub.maxDeliveries > 0 {
+		if n > sub.maxDeliveries {
+			return false
+		}
+		if n == sub.maxDeliveries {
+			eb.unsubscribe(sub)
+		}
+	}
+	return true
+}
+
+// applyFilter 调用订阅的过滤函数，panic 时不投递
+func applyFilter(sub *subscriber, msg *EventMessage) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			lib.Log.Errorf("[EventBus] filter of '%s' panic on event %s (%s): %v", sub.name, msg.Event, msg.ID, r)
+			ok = false
+		}
+	}()
+	return sub.filter(msg)
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscription_Options(t *testing.T) {
+	eb := NewEventBus()
+	var order []string
+	eb.Subscribe("evt", func(msg *EventMessage) { order = append(order, "low") }, WithPriority(-1))
+	eb.Subscribe("evt", func(msg *EventMessage) { order = append(order, "default") })
+	eb.SubscribePattern("*", func(msg *EventMessage) { order = append(order, "high") }, WithPriority(10))
+	even := eb.Subscribe("evt", func(msg *EventMessage) { order = append(order, "even") },
+		WithFilter(func(msg *EventMessage) bool { return msg.Data.(int)%2 == 0 }))
+
+	eb.SyncPublish("evt", 1)
+	eb.SyncPublish("evt", 2)
+	expected := "[high default low high default even low]"
+	if got := fmt.Sprint(order); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if even.Deliveries() != 1 || even.Topic() != "evt" || !even.Active() {
+		t.Errorf("unexpected subscription state: deliveries %d, topic %s", even.Deliveries(), even.Topic())
+	}
+}
+
+func TestSubscription_MaxDeliveriesAndExpiry(t *testing.T) {
+	eb := NewEventBus()
+	var count int32
+	limited := eb.Subscribe("evt", func(msg *EventMessage) { atomic.AddInt32(&count, 1) }, WithMaxDeliveries(3))
+	for i := 0; i < 20; i++ {
+		eb.Publish("evt", i)
+	}
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || limited.Active() || eb.HasSubscribers("evt") {
+		t.Errorf("expected 3 deliveries and the subscription removed, got %d", count)
+	}
+
+	called := false
+	expiring := eb.Subscribe("evt", func(msg *EventMessage) { called = true }, WithExpiry(10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	if expiring.Active() {
+		t.Error("subscription should be expired")
+	}
+	eb.SyncPublish("evt", nil)
+	if called || eb.HasSubscribers("evt") {
+		t.Error("expired subscription should not receive messages and should be removed")
+	}
+}
+
+func TestSubscriptionGroup(t *testing.T) {
+	eb := NewEventBus()
+	group := NewSubscriptionGroup()
+	count := 0
+	eb.Subscribe("a", func(msg *EventMessage) { count++ }, InGroup(group))
+	eb.SubscribePattern("b.>", func(msg *EventMessage) { count++ }, InGroup(group))
+	once := eb.Once("c", func(msg *EventMessage) { count++ }, InGroup(group))
+	other := eb.Subscribe("a", func(msg *EventMessage) { count += 10 })
+	if group.Len() != 3 {
+		t.Fatalf("expected 3 subscriptions in group, got %d", group.Len())
+	}
+	eb.SyncPublish("c", nil)
+	if group.Len() != 2 || once.Active() {
+		t.Errorf("finished Once subscription should leave the group, got %d", group.Len())
+	}
+
+	group.Unsubscribe()
+	eb.SyncPublish("a", nil)
+	eb.SyncPublish("b.x.y", nil)
+	if count != 11 || group.Len() != 0 || !other.Active() {
+		t.Errorf("group subscriptions should be removed and others kept, count %d", count)
+	}
+	if stats := eb.Stats(); stats.Patterns != 0 || stats.Subscribers["a"] != 1 {
+		t.Errorf("unexpected stats after group teardown: %+v", stats)
+	}
+}
@@ -0,0 +1,191 @@
+package events
+
+import "strings"
+
+/**
+
+// 事件名称按 "." 分段，模式中 "*" 匹配任意一段，">" 或 "#" 放在最后匹配之后的一段或多段
+eb.SubscribePattern("order.*.paid", h)  // order.web.paid、order.app.paid
+eb.SubscribePattern("order.*", h)       // order.created，不匹配 order.web.paid
+eb.SubscribePattern("order.>", h)       // order.created、order.web.paid，不匹配 order
+eb.SubscribePattern("#", h)             // 所有事件
+
+*/
+
+const (
+	wildcardOne  = "*" // 匹配一段
+	wildcardMore = ">" // 放在最后时匹配一段或多段
+	wildcardHash = "#" // 同 ">"
+)
+
+// topicTrie 按 "." 分段索引模式订阅，发布时只访问可能匹配的节点
+type topicTrie[V any] struct {
+	root topicNode[V]
+	size int
+}
+
+type topicNode[V any] struct {
+	children map[string]*topicNode[V]
+	values   []V // 以该节点结束的模式的订阅
+	more     []V // 以该节点加 ">" 或 "#" 结束的模式的订阅
+}
+
+// segments 拆分模式，最后一段为 ">" 或 "#" 时 more 为 true 并去掉该段
+func segments(pattern string) (parts []string, more bool) {
+	parts = strings.Split(pattern, ".")
+	if last := parts[len(parts)-1]; last == wildcardMore || last == wildcardHash {
+		return parts[:len(parts)-1], true
+	}
+	return parts, false
+}
+
+// node 返回模式对应的节点，create 为 false 且节点不存在时返回 nil
+func (t *topicTrie[V]) node(parts []string, create bool) *topicNode[V] {
+	n := &t.root
+	for _, part := range parts {
+		child := n.children[part]
+		if child == nil {
+			if !create {
+				return nil
+			}
+			if n.children == nil {
+				n.children = make(map[string]*topicNode[V])
+			}
+			child = &topicNode[V]{}
+			n.children[part] = child
+		}
+		n = child
+	}
+	return n
+}
+
+func (t *topicTrie[V]) add(pattern string, v V) {
+	parts, more := segments(pattern)
+	n := t.node(parts, true)
+	if more {
+		n.more = append(n.more, v)
+	} else {
+		n.values = append(n.values, v)
+	}
+	t.size++
+}
+
+// get 返回模式的所有订阅
+func (t *topicTrie[V]) get(pattern string) []V {
+	parts, more := segments(pattern)
+	n := t.node(parts, false)
+	if n == nil {
+		return nil
+	}
+	if more {
+		return n.more
+	}
+	return n.values
+}
+
+// remove 移除模式中第一个满足 match 的订阅，并清理空节点
+func (t *topicTrie[V]) remove(pattern string, match func(V) bool) bool {
+	parts, more := segments(pattern)
+	path := []*topicNode[V]{&t.root}
+	for _, part := range parts {
+		child := path[len(path)-1].children[part]
+		if child == nil {
+			return false
+		}
+		path = append(path, child)
+	}
+	n := path[len(path)-1]
+	list := &n.values
+	if more {
+		list = &n.more
+	}
+	removed := false
+	for i, v := range *list {
+		if match(v) {
+			*list = append((*list)[:i:i], (*list)[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return false
+	}
+	t.size--
+	for i := len(path) - 1; i > 0; i-- {
+		n := path[i]
+		if len(n.values) > 0 || len(n.more) > 0 || len(n.children) > 0 {
+			break
+		}
+		delete(path[i-1].children, parts[i-1])
+	}
+	return true
+}
+
+// match 对匹配事件名称的每个订阅调用 fn
+func (t *topicTrie[V]) match(topic string, fn func(V)) {
+	if t.size == 0 {
+		return
+	}
+	t.root.match(strings.Split(topic, "."), fn)
+}
+
+func (n *topicNode[V]) match(parts []string, fn func(V)) {
+	if len(parts) == 0 {
+		for _, v := range n.values {
+			fn(v)
+		}
+		return
+	}
+	for _, v := range n.more {
+		fn(v)
+	}
+	if child := n.children[parts[0]]; child != nil {
+		child.match(parts[1:], fn)
+	}
+	if parts[0] != wildcardOne {
+		if child := n.children[wildcardOne]; child != nil {
+			child.match(parts[1:], fn)
+		}
+	}
+}
+
+// each 对所有订阅调用 fn
+func (t *topicTrie[V]) each(fn func(V)) {
+	t.root.each(fn)
+}
+
+func (n *topicNode[V]) each(fn func(V)) {
+	for _, v := range n.values {
+		fn(v)
+	}
+	for _, v := range n.more {
+		fn(v)
+	}
+	for _, child := range n.children {
+		child.each(fn)
+	}
+}
+
+// MatchPattern 判断事件名称是否匹配 SubscribePattern 的模式
+func MatchPattern(pattern, event string) bool {
+	return matchPattern(pattern, event)
+}
+
+// matchPattern 判断事件名称是否匹配模式，规则与 topicTrie 相同
+func matchPattern(pattern, event string) bool {
+	parts, more := segments(pattern)
+	topic := strings.Split(event, ".")
+	if more {
+		if len(topic) <= len(parts) {
+			return false
+		}
+	} else if len(topic) != len(parts) {
+		return false
+	}
+	for i, part := range parts {
+		if part != wildcardOne && part != topic[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopicTrie_Match(t *testing.T) {
+	patterns := []string{"order.*", "order.*.paid", "order.>", "order.#", "*", "#", "order.created", "user.*.*"}
+	tests := []struct {
+		event    string
+		expected []string
+	}{
+		{"order", []string{"#", "*"}},
+		{"order.created", []string{"#", "order.#", "order.*", "order.>", "order.created"}},
+		{"order.web.paid", []string{"#", "order.#", "order.*.paid", "order.>"}},
+		{"order.web.refunded", []string{"#", "order.#", "order.>"}},
+		{"user.profile.updated", []string{"#", "user.*.*"}},
+		{"user.created", []string{"#"}},
+	}
+	var trie topicTrie[string]
+	for _, p := range patterns {
+		trie.add(p, p)
+	}
+	for _, tt := range tests {
+		var got []string
+		trie.match(tt.event, func(p string) { got = append(got, p) })
+		sort.Strings(got)
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.event, tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tt.event, tt.expected, got)
+				break
+			}
+		}
+		for _, p := range patterns {
+			want := false
+			for _, e := range tt.expected {
+				want = want || e == p
+			}
+			if matchPattern(p, tt.event) != want {
+				t.Errorf("matchPattern(%q, %q) should be %v", p, tt.event, want)
+			}
+		}
+	}
+
+	for _, p := range patterns {
+		if !trie.remove(p, func(v string) bool { return v == p }) {
+			t.Errorf("remove %s failed", p)
+		}
+	}
+	if trie.size != 0 || len(trie.root.children) != 0 || len(trie.root.more) != 0 {
+		t.Errorf("trie should be empty after removing all patterns: %+v", trie.root)
+	}
+}
+
+func TestEventBus_WildcardPatterns(t *testing.T) {
+	eb := NewEventBus()
+	var got []string
+	eb.SubscribePattern("order.*.paid", func(msg *EventMessage) { got = append(got, "paid:"+msg.Event) })
+	eb.SubscribePattern("order.>", func(msg *EventMessage) { got = append(got, "all:"+msg.Event) })
+	eb.SyncPublish("order.web.paid", nil)
+	eb.SyncPublish("order.created", nil)
+	eb.SyncPublish("order", nil)
+	expected := []string{"paid:order.web.paid", "all:order.web.paid", "all:order.created"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v in subscription order, got %v", expected, got)
+		}
+	}
+}
+
+func TestGenericEventBus_SubscribePattern(t *testing.T) {
+	eb := NewGenericEventBus[string](nil)
+	var mu sync.Mutex
+	var got []string
+	done := make(chan struct{}, 2)
+	eb.SubscribePattern("user.*.updated", func(ctx context.Context, data string) {
+		mu.Lock()
+		got = append(got, data)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+	eb.Publish(context.Background(), "user.profile.updated", "a", 0)
+	eb.Publish(context.Background(), "user.updated", "b", 0)
+	eb.Publish(context.Background(), "user.avatar.updated", "c", 0)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("pattern handler not called")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("expected [a c], got %v", got)
+	}
+}
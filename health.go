@@ -0,0 +1,94 @@
+package gloop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gloopai/gloop/health"
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules"
+)
+
+// 注册容器与组件的健康检查：
+// container 为就绪检查，启动完成前与停机期间失败；
+// components 为存活检查，有组件处于失败状态时失败；
+// 实现 modules.HealthChecker 的组件各贡献一项就绪检查
+func (c *Container) doRegisterHealthChecks() {
+	c.Health.Register("container", func(ctx context.Context) error {
+		if !c.ready.Load() {
+			return errors.New("container is not ready")
+		}
+		return nil
+	}, health.WithCacheTTL(-1))
+
+	c.Health.Register("components", func(ctx context.Context) error {
+		var failed []string
+		for _, status := range c.ComponentStates() {
+			if status.State == modules.StateFailed {
+				failed = append(failed, status.Component)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("failed components: %s", strings.Join(failed, ", "))
+		}
+		return nil
+	}, health.WithKinds(health.Liveness), health.WithCacheTTL(-1))
+
+	seen := make(map[string]int)
+	for _, comp := range c.components {
+		checker, ok := modules.Unwrap(comp).(modules.HealthChecker)
+		if !ok {
+			continue
+		}
+		name := comp.Name()
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+		c.Health.Register(name, checker.HealthCheck)
+	}
+}
+
+// 配置了 AdminAddr 时启动独立的管理端口，提供健康检查路由
+func (c *Container) doStartAdmin() error {
+	config := c.config()
+	if config == nil || config.AdminAddr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	c.Health.Mount(mux)
+	mux.Handle("/services", c.hub().CatalogHandler())
+
+	listener, err := net.Listen("tcp", config.AdminAddr)
+	if err != nil {
+		return fmt.Errorf("admin listener on %s failed: %w", config.AdminAddr, err)
+	}
+	c.admin = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			lib.Log.Errorf("[Container] admin server error: %v", err)
+		}
+	}(c.admin)
+	lib.Log.Infof("[Container] admin listening on %s", listener.Addr())
+	return nil
+}
+
+// 关闭管理端口
+func (c *Container) doStopAdmin(ctx context.Context) {
+	if c.admin == nil {
+		return
+	}
+	if err := c.admin.Shutdown(ctx); err != nil {
+		lib.Log.Errorf("[Container] admin server shutdown failed: %v", err)
+	}
+	c.admin = nil
+}
@@ -0,0 +1,237 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/**
+
+// 注册一个就绪检查，并挂载到站点
+registry := health.NewRegistry(health.Options{})
+registry.Register("db", func(ctx context.Context) error {
+	return sqlDB.PingContext(ctx)
+}, health.WithTimeout(time.Second))
+registry.Mount(mux)
+
+*/
+
+// Kind 检查类别
+type Kind int
+
+const (
+	Readiness Kind = 1 << iota // 就绪检查，失败时 /readyz 返回 503
+	Liveness                   // 存活检查，失败时 /livez 返回 503
+)
+
+// Status 检查状态
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// CheckFunc 检查函数，返回错误表示不健康
+type CheckFunc func(ctx context.Context) error
+
+// Result 单项检查结果
+type Result struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report 汇总检查结果
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Options 检查注册表配置
+type Options struct {
+	Timeout  time.Duration // 默认单项检查超时时间，默认 3 秒
+	CacheTTL time.Duration // 检查结果缓存时间，默认 2 秒，负数表示不缓存
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	kinds    Kind
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	cached *Result
+}
+
+// Registry 管理健康检查，按类别汇总结果
+type Registry struct {
+	options Options
+	checks  []*check
+	mu      sync.RWMutex
+}
+
+// NewRegistry 创建健康检查注册表
+func NewRegistry(options Options) *Registry {
+	if options.Timeout <= 0 {
+		options.Timeout = 3 * time.Second
+	}
+	if options.CacheTTL == 0 {
+		options.CacheTTL = 2 * time.Second
+	}
+	return &Registry{options: options}
+}
+
+type checkOptions struct {
+	kinds    Kind
+	timeout  time.Duration
+	cacheTTL time.Duration
+}
+
+// CheckOption 检查注册选项
+type CheckOption func(*checkOptions)
+
+// WithKinds 设置检查类别，默认为 Readiness
+func WithKinds(kinds Kind) CheckOption {
+	return func(o *checkOptions) { o.kinds = kinds }
+}
+
+// WithTimeout 设置单项检查超时时间
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(o *checkOptions) { o.timeout = timeout }
+}
+
+// WithCacheTTL 设置单项检查结果缓存时间，负数表示不缓存
+func WithCacheTTL(ttl time.Duration) CheckOption {
+	return func(o *checkOptions) { o.cacheTTL = ttl }
+}
+
+// Register 注册一个检查，同名检查会被替换
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	o := checkOptions{
+		kinds:    Readiness,
+		timeout:  r.options.Timeout,
+		cacheTTL: r.options.CacheTTL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &check{name: name, fn: fn, kinds: o.kinds, timeout: o.timeout, cacheTTL: o.cacheTTL}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.checks {
+		if existing.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Unregister 注销一个检查
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.checks {
+		if existing.name == name {
+			r.checks = append(r.checks[:i], r.checks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Check 并发执行指定类别的检查，kinds 为 0 时执行全部检查
+func (r *Registry) Check(ctx context.Context, kinds Kind) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if kinds == 0 || c.kinds&kinds != 0 {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run 执行检查，缓存未过期时直接返回缓存结果
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && c.cacheTTL > 0 && time.Since(c.cached.CheckedAt) < c.cacheTTL {
+		return *c.cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panic: %v", r)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", c.timeout)
+	}
+
+	result := Result{Name: c.name, Status: StatusUp, Duration: time.Since(start).String(), CheckedAt: start}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.cached = &result
+	return result
+}
+
+// Handler 返回输出指定类别检查结果的 HTTP 处理器，不健康时返回 503
+func (r *Registry) Handler(kinds Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context(), kinds)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != StatusUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Mount 挂载 /healthz（全部检查）、/readyz（就绪检查）、/livez（存活检查）
+func (r *Registry) Mount(mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}) {
+	mux.HandleFunc("/healthz", r.Handler(0))
+	mux.HandleFunc("/readyz", r.Handler(Readiness))
+	mux.HandleFunc("/livez", r.Handler(Liveness))
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistry_KindsAndStatusCode(t *testing.T) {
+	r := NewRegistry(Options{})
+	r.Register("db", func(ctx context.Context) error { return errors.New("down") })
+	r.Register("loop", func(ctx context.Context) error { return nil }, WithKinds(Liveness))
+
+	mux := http.NewServeMux()
+	r.Mount(mux)
+	cases := map[string]int{
+		"/healthz": http.StatusServiceUnavailable,
+		"/readyz":  http.StatusServiceUnavailable,
+		"/livez":   http.StatusOK,
+	}
+	for path, code := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != code {
+			t.Errorf("%s: expected %d, got %d (%s)", path, code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestRegistry_TimeoutAndCache(t *testing.T) {
+	r := NewRegistry(Options{CacheTTL: time.Minute})
+	var calls int32
+	r.Register("slow", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+
+	report := r.Check(context.Background(), 0)
+	if report.Status != StatusDown || report.Checks[0].Error == "" {
+		t.Fatalf("slow check should time out: %+v", report)
+	}
+	r.Check(context.Background(), 0)
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("cached result should be reused, got %d calls", calls)
+	}
+}
@@ -1,10 +1,22 @@
 package lib
 
 import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 type conf struct{}
@@ -53,3 +65,495 @@ func (c *conf) LoadTOML(path string, v interface{}) error {
 
 	return nil
 }
+
+// defaultEnvPrefix 环境变量前缀，GLOOP_SITE__PORT 对应配置 site.port
+const defaultEnvPrefix = "GLOOP_"
+
+// LayerOptions 分层配置加载选项
+type LayerOptions struct {
+	Files     []string // 配置文件，按顺序合并，支持 .toml、.json、.yaml、.yml，不存在的文件会被跳过
+	EnvPrefix string   // 环境变量前缀，默认 GLOOP_，层级之间用双下划线分隔
+	Args      []string // 命令行参数，默认 os.Args[1:]
+	Environ   []string // 环境变量，默认 os.Environ()
+}
+
+// ConfigTree 合并后的配置树
+type ConfigTree map[string]interface{}
+
+/*
+LoadLayered 分层加载配置并解析到 v，优先级从低到高依次为：
+ 1. v 的结构体标签 default:"..." 声明的默认值
+ 2. 配置文件，命令行参数 --config <path> 会替换 opts.Files
+ 3. 以 GLOOP_ 为前缀的环境变量，如 GLOOP_LOG_LEVEL=5、GLOOP_SITE__ADMIN__PORT=8081
+ 4. 以 --config. 为前缀的命令行参数，如 --config.LogLevel=5、--config.site.admin.port 8081，
+    其他命令行参数属于应用自身，不会被读取
+
+环境变量与命令行参数的值按目标字段的类型转换，字符串字段保持原样。
+键名匹配忽略大小写、下划线与连字符。返回合并后的配置树，可用于打印或解析其中的配置段。
+*/
+func (c *conf) LoadLayered(v interface{}, opts LayerOptions) (ConfigTree, error) {
+	if opts.EnvPrefix == "" {
+		opts.EnvPrefix = defaultEnvPrefix
+	}
+	if opts.Args == nil {
+		opts.Args = os.Args[1:]
+	}
+	if opts.Environ == nil {
+		opts.Environ = os.Environ()
+	}
+
+	configPath, flagTree := parseConfigArgs(opts.Args)
+	files := opts.Files
+	if configPath != "" {
+		files = []string{configPath}
+	}
+
+	tree := ConfigTree{}
+	mergeTree(tree, defaultsTree(reflect.TypeOf(v)))
+	for _, path := range files {
+		fileTree, err := loadConfigFile(path)
+		if err != nil {
+			if os.IsNotExist(err) && path != configPath {
+				continue
+			}
+			return nil, fmt.Errorf("load config file %s: %w", path, err)
+		}
+		mergeTree(tree, fileTree)
+	}
+	mergeTree(tree, envTree(opts.Environ, opts.EnvPrefix))
+	mergeTree(tree, flagTree)
+
+	if err := tree.Decode(v); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// LayeredFiles 返回 LoadLayered 实际读取的配置文件列表
+func (c *conf) LayeredFiles(opts LayerOptions) []string {
+	if opts.Args == nil {
+		opts.Args = os.Args[1:]
+	}
+	if configPath, _ := parseConfigArgs(opts.Args); configPath != "" {
+		return []string{configPath}
+	}
+	return opts.Files
+}
+
+// WatchFiles 按 interval 轮询文件的修改时间与大小，发生变化（包括创建、删除）时调用 onChange，
+// 阻塞直到 ctx 结束。轮询方式不依赖平台文件通知，在容器与网络文件系统中同样可用。
+func (c *conf) WatchFiles(ctx context.Context, paths []string, interval time.Duration, onChange func()) {
+	type fileState struct {
+		exists  bool
+		modTime time.Time
+		size    int64
+	}
+	stat := func() []fileState {
+		states := make([]fileState, len(paths))
+		for i, path := range paths {
+			if info, err := os.Stat(path); err == nil {
+				states[i] = fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+		return states
+	}
+
+	last := stat()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := stat()
+			if !reflect.DeepEqual(current, last) {
+				last = current
+				onChange()
+			}
+		}
+	}
+}
+
+// Decode 将配置树解析到 v，键名按 v 的字段名或 toml 标签对齐
+func (t ConfigTree) Decode(v interface{}) error {
+	_, err := t.decode(v)
+	return err
+}
+
+func (t ConfigTree) decode(v interface{}) (toml.MetaData, error) {
+	aligned := alignKeys(map[string]interface{}(t), reflect.TypeOf(v))
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(aligned); err != nil {
+		return toml.MetaData{}, fmt.Errorf("encode config: %w", err)
+	}
+	meta, err := toml.Decode(buf.String(), v)
+	if err != nil {
+		// 行号对应重新编码后的内容，对用户无意义，只保留出错的键
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) && parseErr.LastKey != "" {
+			return meta, fmt.Errorf("decode config key %q: %s", parseErr.LastKey, parseErr.Message)
+		}
+		if m := decodeErrPattern.FindStringSubmatch(err.Error()); m != nil {
+			return meta, fmt.Errorf("decode config key %q: %s", m[1], m[2])
+		}
+		return meta, fmt.Errorf("decode config: %w", err)
+	}
+	return meta, nil
+}
+
+// Section 返回以 . 分隔的路径对应的配置段，键名按 normalizeKey 匹配，不存在时返回 nil
+func (t ConfigTree) Section(path string) ConfigTree {
+	node := map[string]interface{}(t)
+	for _, seg := range strings.Split(path, ".") {
+		var next map[string]interface{}
+		for k, v := range node {
+			if normalizeKey(k) == normalizeKey(seg) {
+				next, _ = v.(map[string]interface{})
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return ConfigTree(node)
+}
+
+// WithSection 返回替换了路径对应配置段的副本，section 为 nil 时删除该配置段，t 本身不会被修改
+func (t ConfigTree) WithSection(path string, section ConfigTree) ConfigTree {
+	return ConfigTree(withSection(t, strings.Split(path, "."), section))
+}
+
+func withSection(node map[string]interface{}, path []string, section ConfigTree) map[string]interface{} {
+	res := make(map[string]interface{}, len(node)+1)
+	key := path[0]
+	for k, v := range node {
+		if normalizeKey(k) == normalizeKey(path[0]) {
+			key = k
+			continue
+		}
+		res[k] = v
+	}
+	if len(path) == 1 {
+		if section != nil {
+			res[key] = map[string]interface{}(section)
+		}
+		return res
+	}
+	child, _ := node[key].(map[string]interface{})
+	if next := withSection(child, path[1:], section); len(next) > 0 {
+		res[key] = next
+	}
+	return res
+}
+
+// DecodeStrict 同 Decode，但存在无法对应到 v 字段的键时返回错误，错误中列出这些键
+func (t ConfigTree) DecodeStrict(v interface{}) error {
+	meta, err := t.decode(v)
+	if err != nil {
+		return err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		return &ErrUnknownConfigKeys{Keys: keys}
+	}
+	return nil
+}
+
+// ErrUnknownConfigKeys 配置中存在无法识别的键
+type ErrUnknownConfigKeys struct {
+	Keys []string
+}
+
+func (e *ErrUnknownConfigKeys) Error() string {
+	return "unknown config keys: " + strings.Join(e.Keys, ", ")
+}
+
+// Redacted 返回隐藏了密钥、密码、令牌等敏感值的配置树副本
+func (t ConfigTree) Redacted() ConfigTree {
+	res := ConfigTree{}
+	for k, v := range t {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			res[k] = map[string]interface{}(ConfigTree(val).Redacted())
+		default:
+			if isSecretKey(k) {
+				res[k] = "******"
+			} else {
+				res[k] = v
+			}
+		}
+	}
+	return res
+}
+
+// String 以 TOML 格式输出配置树
+func (t ConfigTree) String() string {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]interface{}(t)); err != nil {
+		return fmt.Sprintf("<invalid config: %v>", err)
+	}
+	return buf.String()
+}
+
+var decodeErrPattern = regexp.MustCompile(`^toml: (?:line \d+ )?\(last key "(.*)"\): (.*)$`)
+
+var secretKeyPattern = regexp.MustCompile(`(?i)(secret|password|passwd|token|credential|private|apikey|api_key)`)
+
+func isSecretKey(key string) bool {
+	return secretKeyPattern.MatchString(key)
+}
+
+// normalizeKey 键名比较时忽略大小写、下划线与连字符
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
+
+// mergeTree 将 src 深度合并到 dst，键名按 normalizeKey 匹配
+func mergeTree(dst, src map[string]interface{}) {
+	for k, v := range src {
+		key := k
+		for existing := range dst {
+			if normalizeKey(existing) == normalizeKey(k) {
+				key = existing
+				break
+			}
+		}
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeTree(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := map[string]interface{}{}
+			mergeTree(copied, srcMap)
+			v = copied
+		}
+		dst[key] = v
+	}
+}
+
+// setPath 按路径设置值，路径各段按 normalizeKey 匹配已有键
+func setPath(tree map[string]interface{}, path []string, value interface{}) {
+	node := map[string]interface{}{}
+	leaf := node
+	for i, seg := range path {
+		if i == len(path)-1 {
+			leaf[seg] = value
+			break
+		}
+		next := map[string]interface{}{}
+		leaf[seg] = next
+		leaf = next
+	}
+	mergeTree(tree, node)
+}
+
+// rawValue 环境变量、命令行参数与 default 标签中的原始字符串，解析时由 coerceValue 按字段类型转换
+type rawValue string
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// coerceValue 按目标类型转换原始字符串：字符串字段与实现 encoding.TextUnmarshaler 的字段保持原样，
+// 其他字段或类型未知时按 TOML 值语法解析，无法解析时作为普通字符串
+func coerceValue(raw string, t reflect.Type) interface{} {
+	if t = indirectType(t); t != nil && (t.Kind() == reflect.String || reflect.PointerTo(t).Implements(textUnmarshalerType)) {
+		return raw
+	}
+	var holder struct{ V interface{} }
+	if _, err := toml.Decode("V = "+raw, &holder); err == nil && holder.V != nil {
+		return holder.V
+	}
+	return raw
+}
+
+// envTree 读取带前缀的环境变量，双下划线表示层级
+func envTree(environ []string, prefix string) map[string]interface{} {
+	tree := map[string]interface{}{}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		path := strings.Split(strings.TrimPrefix(name, prefix), "__")
+		for i := range path {
+			path[i] = strings.ToLower(path[i])
+		}
+		setPath(tree, path, rawValue(value))
+	}
+	return tree
+}
+
+// configArgPrefix 配置参数的前缀，--config.site.port=8081 对应配置 site.port
+const configArgPrefix = "--config."
+
+// parseConfigArgs 解析 --config 路径与 --config.key.path=value 形式的配置参数，
+// 其他参数属于应用自身，会被忽略；没有值的参数视为 true
+func parseConfigArgs(args []string) (string, map[string]interface{}) {
+	configPath := ""
+	tree := map[string]interface{}{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg != "--config" && !strings.HasPrefix(arg, "--config=") && !strings.HasPrefix(arg, configArgPrefix) {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !hasValue {
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				value = args[i+1]
+				i++
+			} else {
+				value = "true"
+			}
+		}
+		if name == "config" {
+			configPath = value
+			continue
+		}
+		setPath(tree, strings.Split(strings.TrimPrefix(name, "config."), "."), rawValue(value))
+	}
+	return configPath, tree
+}
+
+// loadConfigFile 按扩展名读取 TOML、JSON 或 YAML 配置文件
+func loadConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&tree); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+	default:
+		if _, err := toml.Decode(string(data), &tree); err != nil {
+			return nil, err
+		}
+	}
+	return normalizeValue(tree).(map[string]interface{}), nil
+}
+
+// normalizeValue 将 JSON、YAML 解析出的值转换为 TOML 可编码的类型
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if item == nil {
+				delete(val, k) // TOML 不支持空值
+				continue
+			}
+			val[k] = normalizeValue(item)
+		}
+		return val
+	case map[interface{}]interface{}:
+		res := map[string]interface{}{}
+		for k, item := range val {
+			if item != nil {
+				res[fmt.Sprint(k)] = normalizeValue(item)
+			}
+		}
+		return res
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeValue(item)
+		}
+		return val
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case int:
+		return int64(val)
+	default:
+		return v
+	}
+}
+
+// fieldKey 返回结构体字段在配置中的键名
+func fieldKey(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("toml"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return field.Name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// defaultsTree 根据结构体标签 default:"..." 生成默认值配置树
+func defaultsTree(t reflect.Type) map[string]interface{} {
+	tree := map[string]interface{}{}
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return tree
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("toml") == "-" {
+			continue
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			tree[fieldKey(field)] = rawValue(def)
+			continue
+		}
+		if indirectType(field.Type).Kind() == reflect.Struct {
+			if sub := defaultsTree(field.Type); len(sub) > 0 {
+				tree[fieldKey(field)] = sub
+			}
+		}
+	}
+	return tree
+}
+
+// alignKeys 将配置树的键名对齐为结构体字段的键名，并按字段类型转换原始字符串，以便 TOML 解析
+func alignKeys(tree map[string]interface{}, t reflect.Type) map[string]interface{} {
+	t = indirectType(t)
+	res := make(map[string]interface{}, len(tree))
+	for k, v := range tree {
+		key := k
+		var valueType reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if field.IsExported() && (normalizeKey(fieldKey(field)) == normalizeKey(k) || normalizeKey(field.Name) == normalizeKey(k)) {
+					key = fieldKey(field)
+					valueType = field.Type
+					break
+				}
+			}
+		} else if t != nil && t.Kind() == reflect.Map {
+			valueType = t.Elem()
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			v = alignKeys(val, valueType)
+		case rawValue:
+			v = coerceValue(string(val), valueType)
+		}
+		res[key] = v
+	}
+	return res
+}
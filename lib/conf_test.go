@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type layeredTestConfig struct {
+	LogLevel        int           `default:"4"`
+	Debug           bool          `default:"false"`
+	ShutdownTimeout time.Duration `default:"30s"`
+	Name            string        `toml:"name" default:"gloop"`
+	Site            struct {
+		Port      int    `toml:"port" default:"8080"`
+		SecretKey string `toml:"secret_key"`
+	} `toml:"site"`
+}
+
+func TestConf_LoadLayered(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "container.yaml")
+	if err := os.WriteFile(yamlPath, []byte("log_level: 2\nsite:\n  port: 9000\n  secret_key: abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "override.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"Debug": true, "ShutdownTimeout": "5s"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg layeredTestConfig
+	tree, err := Conf.LoadLayered(&cfg, LayerOptions{
+		Files:   []string{yamlPath, jsonPath, filepath.Join(dir, "missing.toml")},
+		Environ: []string{"GLOOP_SITE__PORT=9100", "GLOOP_NAME=from-env", "OTHER=1"},
+		Args:    []string{"-test.v", "--config.log-level=5", "--config.name", "from-flag", "--name", "app-flag", "--debug=false"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != 5 || !cfg.Debug || cfg.ShutdownTimeout != 5*time.Second {
+		t.Errorf("unexpected top level config: %+v", cfg)
+	}
+	if cfg.Name != "from-flag" || cfg.Site.Port != 9100 || cfg.Site.SecretKey != "abc" {
+		t.Errorf("unexpected overrides: %+v", cfg)
+	}
+	dump := tree.Redacted().String()
+	if strings.Contains(dump, "abc") || !strings.Contains(dump, "******") {
+		t.Errorf("secret should be redacted:\n%s", dump)
+	}
+}
+
+func TestConf_LoadLayeredDefaultsAndConfigFlag(t *testing.T) {
+	var cfg layeredTestConfig
+	if _, err := Conf.LoadLayered(&cfg, LayerOptions{Args: []string{}, Environ: []string{}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != 4 || cfg.ShutdownTimeout != 30*time.Second || cfg.Name != "gloop" || cfg.Site.Port != 8080 {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+
+	_, err := Conf.LoadLayered(&cfg, LayerOptions{Args: []string{"--config", "/nonexistent/gloop.toml"}, Environ: []string{}})
+	if err == nil {
+		t.Error("explicit --config path should be required")
+	}
+}
+
+func TestConf_LoadLayeredKeepsStringFields(t *testing.T) {
+	var cfg layeredTestConfig
+	_, err := Conf.LoadLayered(&cfg, LayerOptions{
+		Environ: []string{"GLOOP_NAME=123456", "GLOOP_SITE__PORT=9100"},
+		Args:    []string{"--config.site.secret_key=true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "123456" || cfg.Site.SecretKey != "true" || cfg.Site.Port != 9100 {
+		t.Errorf("numeric and boolean strings should stay strings in string fields: %+v", cfg)
+	}
+
+	// 字符串字段中的 # 与引号原样保留
+	_, err = Conf.LoadLayered(&cfg, LayerOptions{
+		Environ: []string{`GLOOP_NAME="quoted" # not a comment`, "GLOOP_SITE__SECRET_KEY=1979-05-27"},
+		Args:    []string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != `"quoted" # not a comment` || cfg.Site.SecretKey != "1979-05-27" {
+		t.Errorf("string values should not be parsed as TOML: %+v", cfg)
+	}
+}
@@ -108,6 +108,14 @@ func (l *log) Fatalf(format string, args ...interface{}) {
 	l.logger.Fatalf(format, args...)
 }
 
+// Flush flushes buffered log output if the underlying writer supports it
+func (l *log) Flush() error {
+	if syncer, ok := l.logger.Out.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
 // LogFormatter is a custom type that wraps logrus.Formatter
 type LogFormatter logrus.Formatter
 
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,7 +16,11 @@ var Request request
 
 // http post json
 func (r *request) HttpPostJson(url string, data interface{}, header map[string]interface{}) ([]byte, error) {
-	// b, _ := json.Marshal(data)
+	return r.HttpPostJsonWithContext(context.Background(), url, data, header)
+}
+
+// http post json，请求受 ctx 控制，可被取消或超时
+func (r *request) HttpPostJsonWithContext(ctx context.Context, url string, data interface{}, header map[string]interface{}) ([]byte, error) {
 	b := &bytes.Buffer{}
 	enc := json.NewEncoder(b)
 	enc.SetEscapeHTML(false)
@@ -25,7 +30,10 @@ func (r *request) HttpPostJson(url string, data interface{}, header map[string]i
 	}
 
 	Log.Info(fmt.Sprintf("HTTP POST JSON: %s data:%v", url, b))
-	request, _ := http.NewRequest("POST", url, b)
+	request, err := http.NewRequestWithContext(ctx, "POST", url, b)
+	if err != nil {
+		return nil, err
+	}
 	request.Header.Set("Content-Type", "application/json")
 	for k, v := range header {
 		request.Header.Set(k, fmt.Sprintf("%v", v))
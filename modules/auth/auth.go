@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/gloopai/gloop/lib"
 	"github.com/gloopai/gloop/modules"
@@ -13,6 +14,8 @@ type Auth struct {
 	Config     AuthOptions // 认证配置
 	db         *db.DbService
 	JWTManager *JWTManager // JWT 管理器
+
+	initErr error // Init 过程中的错误
 }
 
 func NewAuth(opt AuthOptions) *Auth {
@@ -24,10 +27,53 @@ func NewAuth(opt AuthOptions) *Auth {
 func (a *Auth) Name() string {
 	return "auth"
 }
+
+/* 容器配置段：[auth] */
+func (a *Auth) ConfigSection() string {
+	return a.Name()
+}
+
+func (a *Auth) ConfigOptions() any {
+	return &a.Config
+}
+
+/* 配置热加载：令牌有效期立即生效，其余 JWT 配置需重启后生效 */
+func (a *Auth) Reconfigure(old, new any) error {
+	prev, ok := old.(*AuthOptions)
+	next, ok2 := new.(*AuthOptions)
+	if !ok || !ok2 {
+		return fmt.Errorf("unexpected auth options type %T", new)
+	}
+	if next.JWTOptions.SecretKey != prev.JWTOptions.SecretKey || next.JWTOptions.Authorization != prev.JWTOptions.Authorization {
+		lib.Log.Warn("[auth] secret_key and authorization changes take effect after restart")
+	}
+	if next.JWTOptions.TokenDuration > 0 && a.JWTManager != nil {
+		a.Config.JWTOptions.TokenDuration = next.JWTOptions.TokenDuration
+		a.JWTManager.SetTokenDuration(time.Hour * time.Duration(next.JWTOptions.TokenDuration))
+		lib.Log.Infof("[auth] reconfigured: TokenDuration=%dh", next.JWTOptions.TokenDuration)
+	}
+	return nil
+}
+
+/* 数据库组件添加到容器时先于认证模块初始化，未添加时由使用者自行初始化 */
+func (a *Auth) DependsOn() []modules.Dependency {
+	if a.db == nil {
+		return nil
+	}
+	return []modules.Dependency{modules.OptionalDependOnType[*db.DbService]()}
+}
+
 func (a *Auth) Init() {
+	a.initErr = nil
+	if a.db == nil {
+		a.initErr = fmt.Errorf("auth requires a db service")
+		lib.Log.Error("Failed to init auth:", a.initErr)
+		return
+	}
 	err := EnsureAuthTableExists(a.db.Db)
 	if err != nil {
 		lib.Log.Error("Failed to ensure auth table exists:", err)
+		a.initErr = fmt.Errorf("failed to ensure auth table exists: %w", err)
 		return
 	}
 
@@ -38,6 +84,11 @@ func (a *Auth) Init() {
 	a.JWTManager = NewJWTManager(a.Config.JWTOptions)
 }
 
+/* 返回 Init 过程中的错误 */
+func (a *Auth) InitError() error {
+	return a.initErr
+}
+
 func (a *Auth) Start() error {
 	return nil
 }
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/gloopai/gloop/modules"
@@ -9,7 +10,7 @@ import (
 
 type JWTManager struct {
 	secretKey     string
-	tokenDuration time.Duration
+	tokenDuration atomic.Int64 // 令牌有效期，支持运行时修改
 }
 
 type AuthJwtClaims struct {
@@ -33,10 +34,21 @@ func NewJWTManager(opt JWTOptions) *JWTManager {
 		opt.TokenDuration = 24 * 365 // Default token duration is 24 hours
 	}
 
-	return &JWTManager{
-		secretKey:     opt.SecretKey,
-		tokenDuration: time.Duration(time.Hour * time.Duration(opt.TokenDuration)),
+	j := &JWTManager{
+		secretKey: opt.SecretKey,
 	}
+	j.SetTokenDuration(time.Duration(time.Hour * time.Duration(opt.TokenDuration)))
+	return j
+}
+
+// SetTokenDuration 修改之后签发的令牌的有效期
+func (j *JWTManager) SetTokenDuration(d time.Duration) {
+	j.tokenDuration.Store(int64(d))
+}
+
+// TokenDuration 返回令牌有效期
+func (j *JWTManager) TokenDuration() time.Duration {
+	return time.Duration(j.tokenDuration.Load())
 }
 
 func (j *JWTManager) GenerateToken(auth modules.RequestAuth) (string, error) {
@@ -44,7 +56,7 @@ func (j *JWTManager) GenerateToken(auth modules.RequestAuth) (string, error) {
 		UserId:   auth.UserId,
 		UserName: auth.Username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.tokenDuration)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.TokenDuration())),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -3,6 +3,6 @@ package auth
 import "github.com/gloopai/gloop/modules/db"
 
 type AuthOptions struct {
-	Db         *db.DbService
-	JWTOptions JWTOptions `json:"jwt_options"` // JWT 选项
+	Db         *db.DbService `toml:"-"`
+	JWTOptions JWTOptions    `json:"jwt_options"` // JWT 选项
 }
@@ -1,5 +1,7 @@
 package modules
 
+import "github.com/gloopai/gloop/events"
+
 type Component interface {
 	// Name 组件名称
 	Name() string
@@ -20,7 +22,8 @@ type Component interface {
 }
 
 type Base struct {
-	ctx *ComponentContext
+	ctx  *ComponentContext
+	subs *events.SubscriptionGroup
 }
 
 // Name 组件名称
@@ -49,3 +52,18 @@ func (b *Base) SetContext(ctx *ComponentContext) {
 func (b *Base) GetContext() *ComponentContext {
 	return b.ctx
 }
+
+// Subscriptions 组件的订阅组，使用 events.InGroup 加入的订阅在组件停止时（Destroy 之后）由容器取消
+func (b *Base) Subscriptions() *events.SubscriptionGroup {
+	if b.subs == nil {
+		b.subs = events.NewSubscriptionGroup()
+	}
+	return b.subs
+}
+
+// unsubscribeAll 取消组件订阅组中的所有订阅
+func (b *Base) unsubscribeAll() {
+	if b.subs != nil {
+		b.subs.Unsubscribe()
+	}
+}
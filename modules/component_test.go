@@ -0,0 +1,37 @@
+package modules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gloopai/gloop/events"
+)
+
+type listenerComponent struct {
+	Base
+	received int
+}
+
+func (c *listenerComponent) Name() string { return "listener" }
+
+func (c *listenerComponent) Init() {
+	c.GetContext().Events.Subscribe("ping", func(msg *events.EventMessage) { c.received++ }, events.InGroup(c.Subscriptions()))
+}
+
+func TestBase_SubscriptionsRemovedOnStop(t *testing.T) {
+	eb := events.NewEventBus()
+	comp := &listenerComponent{}
+	adapted := Adapt(comp)
+	ctx := WithComponentContext(context.Background(), &ComponentContext{Events: eb})
+	if err := adapted.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	eb.SyncPublish("ping", nil)
+	if err := adapted.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	eb.SyncPublish("ping", nil)
+	if comp.received != 1 || eb.HasSubscribers("ping") {
+		t.Errorf("subscriptions should be removed when the component stops, received %d", comp.received)
+	}
+}
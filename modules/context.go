@@ -1,5 +1,12 @@
 package modules
 
+import (
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/servicehub"
+)
+
 type ComponentContext struct {
-	Node *Node
+	Node   *Node
+	Events *events.EventBus       // 容器事件总线，发布组件状态变化等事件
+	Hub    *servicehub.ServiceHub // 容器的服务中心
 }
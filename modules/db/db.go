@@ -1,10 +1,12 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/gloopai/gloop/lib"
 	"github.com/gloopai/gloop/modules"
 
 	"gorm.io/driver/sqlite"
@@ -15,15 +17,19 @@ import (
 
 type DbService struct {
 	modules.Base
-	Id   string // 数据库 ID
-	Path string // 数据库路径
-	Db   *gorm.DB
+	Id     string    // 数据库 ID
+	Path   string    // 数据库路径
+	Config DbOptions // 数据库配置
+	Db     *gorm.DB
+
+	initErr error // Init 过程中的错误
 }
 
 // NewDb 创建一个新的数据库实例
 func NewDb(opt DbOptions) *DbService {
 	return &DbService{
-		Path: opt.DbPath,
+		Path:   opt.DbPath,
+		Config: opt,
 	}
 }
 
@@ -33,7 +39,11 @@ func (d *DbService) Name() string {
 
 // 修改 Init 方法以保存数据库连接，并提供一个方法获取连接
 func (d *DbService) Init() {
+	if d.Config.DbPath != "" {
+		d.Path = d.Config.DbPath
+	}
 	d.printInfo()
+	d.initErr = nil
 
 	// 检查数据库文件夹是否存在，不存在则创建
 	dir := filepath.Dir(d.Path)
@@ -41,6 +51,7 @@ func (d *DbService) Init() {
 		err := os.MkdirAll(dir, 0755)
 		if err != nil {
 			fmt.Printf("failed to create database directory: %v\n", err)
+			d.initErr = fmt.Errorf("failed to create database directory: %w", err)
 			return
 		}
 	}
@@ -50,6 +61,7 @@ func (d *DbService) Init() {
 		file, err := os.Create(d.Path)
 		if err != nil {
 			fmt.Printf("failed to create database file: %v\n", err)
+			d.initErr = fmt.Errorf("failed to create database file: %w", err)
 			return
 		}
 		file.Close()
@@ -72,6 +84,7 @@ func (d *DbService) Init() {
 	if err != nil {
 		fmt.Printf("failed to open database: %v\n", err)
 		d.Db = nil
+		d.initErr = fmt.Errorf("failed to open database: %w", err)
 		return
 	}
 	// 将数据库连接保存到结构体中
@@ -79,8 +92,50 @@ func (d *DbService) Init() {
 	// lib.Log.Info("SQLite database initialized successfully")
 }
 
-/*  */
-func (d *DbService) Close() {}
+/* 容器配置段：[db.<name>]，未设置名称时为 [db] */
+func (d *DbService) ConfigSection() string {
+	if d.Config.Name == "" {
+		return d.Name()
+	}
+	return d.Name() + "." + d.Config.Name
+}
+
+func (d *DbService) ConfigOptions() any {
+	return &d.Config
+}
+
+/* 返回 Init 过程中的错误 */
+func (d *DbService) InitError() error {
+	return d.initErr
+}
+
+/* 健康检查：确认 SQLite 连接可用 */
+func (d *DbService) HealthCheck(ctx context.Context) error {
+	if d.Db == nil {
+		return fmt.Errorf("database is not open")
+	}
+	sqlDB, err := d.Db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+/* 关闭数据库连接 */
+func (d *DbService) Close() {
+	if d.Db == nil {
+		return
+	}
+	sqlDB, err := d.Db.DB()
+	if err != nil {
+		lib.Log.Errorf("[db] get database connection failed: %v", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		lib.Log.Errorf("[db] close database failed: %v", err)
+	}
+	d.Db = nil
+}
 
 func (d *DbService) printInfo() {
 	infos := make([]string, 0, 2)
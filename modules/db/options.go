@@ -1,6 +1,6 @@
 package db
 
 type DbOptions struct {
-	DbPath string `json:"db_path"` // 数据库路径
-	Name   string `json:"name"`    // 数据库名称
+	DbPath string `json:"db_path" validate:"required" validate_msg:"db_path 不能为空"` // 数据库路径
+	Name   string `json:"name"`                                                    // 数据库名称
 }
@@ -0,0 +1,158 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dependency 描述组件的一个依赖，按名称或类型匹配
+type Dependency struct {
+	Name string       // 依赖组件名称，对应 Component.Name()
+	Type reflect.Type // 依赖组件类型，接口类型按实现关系匹配
+	// Optional 为 true 时依赖的组件未添加到容器不报错，已添加时仍排在前面
+	Optional bool
+}
+
+// Dependent 可选接口，组件通过它声明依赖，容器据此排序生命周期
+type Dependent interface {
+	DependsOn() []Dependency
+}
+
+// DependOn 按组件名称声明依赖
+func DependOn(name string) Dependency {
+	return Dependency{Name: name}
+}
+
+// DependOnType 按组件类型声明依赖，例如 DependOnType[*db.DbService]()
+func DependOnType[T any]() Dependency {
+	return Dependency{Type: reflect.TypeOf((*T)(nil)).Elem()}
+}
+
+// OptionalDependOnType 按组件类型声明可选依赖，依赖的组件可由使用者自行初始化而不添加到容器
+func OptionalDependOnType[T any]() Dependency {
+	d := DependOnType[T]()
+	d.Optional = true
+	return d
+}
+
+func (d Dependency) String() string {
+	if d.Type != nil {
+		return d.Type.String()
+	}
+	return d.Name
+}
+
+// matches 判断组件是否满足该依赖，适配后的 v1 组件按原始类型匹配
+func (d Dependency) matches(comp namedComponent) bool {
+	if d.Type != nil {
+		var target any = comp
+		if adapted, ok := comp.(interface{ Unwrap() Component }); ok {
+			target = adapted.Unwrap()
+		}
+		t := reflect.TypeOf(target)
+		if d.Type.Kind() == reflect.Interface {
+			return t.Implements(d.Type)
+		}
+		return t == d.Type
+	}
+	return comp.Name() == d.Name
+}
+
+// ErrMissingDependency 组件依赖的组件未添加到容器
+type ErrMissingDependency struct {
+	Component  string
+	Dependency Dependency
+}
+
+func (e *ErrMissingDependency) Error() string {
+	return fmt.Sprintf("component '%s' depends on '%s', which is not added to the container", e.Component, e.Dependency)
+}
+
+// ErrDependencyCycle 组件之间存在循环依赖
+type ErrDependencyCycle struct {
+	Path []string
+}
+
+func (e *ErrDependencyCycle) Error() string {
+	return "component dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// namedComponent Component 与 ComponentV2 的公共部分
+type namedComponent interface {
+	Name() string
+}
+
+// SortComponents 按依赖关系对组件拓扑排序，被依赖的组件排在前面。
+// 没有依赖关系的组件保持添加顺序。
+func SortComponents[T namedComponent](components []T) ([]T, error) {
+	// edges[i] 为组件 i 依赖的组件下标
+	edges := make([][]int, len(components))
+	for i, comp := range components {
+		dependent, ok := any(comp).(Dependent)
+		if !ok {
+			continue
+		}
+		for _, dep := range dependent.DependsOn() {
+			found := false
+			for j, other := range components {
+				if i != j && dep.matches(other) {
+					edges[i] = append(edges[i], j)
+					found = true
+				}
+			}
+			if !found && !dep.Optional {
+				return nil, &ErrMissingDependency{Component: comp.Name(), Dependency: dep}
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(components))
+	sorted := make([]T, 0, len(components))
+	var stack []int
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			// a -> b 表示 a 依赖 b
+			start := 0
+			for k, idx := range stack {
+				if idx == i {
+					start = k
+				}
+			}
+			path := make([]string, 0, len(stack)-start+1)
+			for _, idx := range stack[start:] {
+				path = append(path, components[idx].Name())
+			}
+			path = append(path, components[i].Name())
+			return &ErrDependencyCycle{Path: path}
+		}
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, j := range edges[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		sorted = append(sorted, components[i])
+		return nil
+	}
+
+	for i := range components {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
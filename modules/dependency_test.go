@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"errors"
+	"testing"
+)
+
+type depComponent struct {
+	Base
+	name string
+	deps []Dependency
+}
+
+func (c *depComponent) Name() string            { return c.name }
+func (c *depComponent) DependsOn() []Dependency { return c.deps }
+
+type dbComponent struct{ Base }
+
+func (c *dbComponent) Name() string { return "db" }
+
+func names(comps []Component) []string {
+	res := make([]string, 0, len(comps))
+	for _, c := range comps {
+		res = append(res, c.Name())
+	}
+	return res
+}
+
+func TestSortComponents_Order(t *testing.T) {
+	site := &depComponent{name: "site", deps: []Dependency{DependOn("auth")}}
+	auth := &depComponent{name: "auth", deps: []Dependency{DependOnType[*dbComponent]()}}
+	db := &dbComponent{}
+	other := &depComponent{name: "other"}
+
+	sorted, err := SortComponents([]Component{site, other, auth, db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := names(sorted)
+	want := []string{"db", "auth", "site", "other"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected order: %v", got)
+		}
+	}
+}
+
+func TestSortComponents_Missing(t *testing.T) {
+	auth := &depComponent{name: "auth", deps: []Dependency{DependOnType[*dbComponent]()}}
+	_, err := SortComponents([]Component{auth})
+	var missing *ErrMissingDependency
+	if !errors.As(err, &missing) || missing.Component != "auth" {
+		t.Fatalf("expected missing dependency error, got %v", err)
+	}
+}
+
+func TestSortComponents_Optional(t *testing.T) {
+	site := &depComponent{name: "site", deps: []Dependency{OptionalDependOnType[*dbComponent]()}}
+	sorted, err := SortComponents([]Component{site})
+	if err != nil || len(sorted) != 1 {
+		t.Fatalf("missing optional dependency should be ignored, got %v", err)
+	}
+	sorted, err = SortComponents([]Component{site, &dbComponent{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(sorted); got[0] != "db" || got[1] != "site" {
+		t.Fatalf("optional dependency should still be ordered first: %v", got)
+	}
+}
+
+func TestSortComponents_Cycle(t *testing.T) {
+	a := &depComponent{name: "a", deps: []Dependency{DependOn("b")}}
+	b := &depComponent{name: "b", deps: []Dependency{DependOn("c")}}
+	c := &depComponent{name: "c", deps: []Dependency{DependOn("a")}}
+	_, err := SortComponents([]Component{a, b, c})
+	var cycle *ErrDependencyCycle
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if err.Error() != "component dependency cycle: a -> b -> c -> a" {
+		t.Fatalf("unexpected cycle message: %v", err)
+	}
+}
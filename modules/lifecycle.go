@@ -0,0 +1,235 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+// ComponentV2 支持 context 与错误返回的组件生命周期。
+// Start 启动后应立即返回，不应阻塞；Stop 需要能处理仅完成 Init 而未启动的情况。
+type ComponentV2 interface {
+	// Name 组件名称
+	Name() string
+	// Init 初始化组件
+	Init(ctx context.Context) error
+	// Start 启动组件
+	Start(ctx context.Context) error
+	// Stop 停止组件并释放资源
+	Stop(ctx context.Context) error
+}
+
+// ServiceRegistrar 可选接口，ComponentV2 通过它在启动前注册服务
+type ServiceRegistrar interface {
+	RegisterService(ctx context.Context) error
+}
+
+// Drainer 可选接口，停机时先于 Stop 调用，用于停止接收新请求并等待处理中的请求完成
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// HealthChecker 可选接口，组件通过它向容器的健康检查贡献一项就绪检查
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Configurable 可选接口，容器在 Init 之前将配置文件中名为 ConfigSection 的配置段
+// （如 [site.admin]、[db.main]、[auth]）解析到 ConfigOptions 返回的结构体指针，
+// 未出现的键保留原值，解析后按 validate 标签校验
+type Configurable interface {
+	ConfigSection() string
+	ConfigOptions() any
+}
+
+// Reconfigurable 可选接口，配置热加载时，组件配置段发生变化且新配置通过校验后调用，
+// old、new 为与 ConfigOptions 同类型的结构体指针。返回错误表示拒绝新配置，组件应保持原配置。
+type Reconfigurable interface {
+	Reconfigure(old, new any) error
+}
+
+// InitErrorReporter 可选接口，v1 组件通过它报告 Init 过程中的错误
+type InitErrorReporter interface {
+	InitError() error
+}
+
+// BlockingStarter 可选接口，v1 组件的 Start 一直阻塞运行（如直接调用 ListenAndServe）时实现并返回 true，
+// 容器将 Start 放入独立 goroutine 并立即视为启动成功，由 Run 等待其返回。
+// 未实现时 Start 视为同步启动，返回后才启动依赖它的组件
+type BlockingStarter interface {
+	BlockingStart() bool
+}
+
+// legacySlowStart 同步启动的 v1 组件超过该时间未返回时输出警告
+const legacySlowStart = 10 * time.Second
+
+type componentContextKey struct{}
+
+// WithComponentContext 将组件上下文写入 context
+func WithComponentContext(ctx context.Context, cctx *ComponentContext) context.Context {
+	return context.WithValue(ctx, componentContextKey{}, cctx)
+}
+
+// ComponentContextFrom 从 context 中读取组件上下文
+func ComponentContextFrom(ctx context.Context) *ComponentContext {
+	cctx, _ := ctx.Value(componentContextKey{}).(*ComponentContext)
+	return cctx
+}
+
+// legacyComponent 将 v1 Component 适配为 ComponentV2
+type legacyComponent struct {
+	comp    Component
+	mu      sync.Mutex
+	running chan error // Start 阻塞运行时，用于接收其最终返回值
+}
+
+// Adapt 将 v1 组件（如嵌入 modules.Base 的组件）适配为 ComponentV2。
+// Init、RegisterService、Start 中的 panic 会被转换为错误；
+// 组件实现 InitErrorReporter 时，Init 之后会读取其报告的错误。
+func Adapt(comp Component) ComponentV2 {
+	return &legacyComponent{comp: comp}
+}
+
+// Unwrap 返回适配前的 v1 组件，非适配组件原样返回，用于检查组件实现的可选接口
+func Unwrap(comp ComponentV2) any {
+	if adapted, ok := comp.(*legacyComponent); ok {
+		return adapted.comp
+	}
+	return comp
+}
+
+// Unwrap 返回被适配的 v1 组件
+func (l *legacyComponent) Unwrap() Component {
+	return l.comp
+}
+
+func (l *legacyComponent) Name() string {
+	return l.comp.Name()
+}
+
+func (l *legacyComponent) DependsOn() []Dependency {
+	if dependent, ok := l.comp.(Dependent); ok {
+		return dependent.DependsOn()
+	}
+	return nil
+}
+
+func (l *legacyComponent) Init(ctx context.Context) error {
+	l.comp.SetContext(ComponentContextFrom(ctx))
+	if err := safeCall(l.comp.Name(), "init", func() error {
+		l.comp.Init()
+		return nil
+	}); err != nil {
+		return err
+	}
+	if reporter, ok := l.comp.(InitErrorReporter); ok {
+		return reporter.InitError()
+	}
+	return nil
+}
+
+func (l *legacyComponent) RegisterService(ctx context.Context) error {
+	return safeCall(l.comp.Name(), "register service", func() error {
+		l.comp.RegisterService()
+		return nil
+	})
+}
+
+func (l *legacyComponent) Start(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- safeCall(l.comp.Name(), "start", l.comp.Start)
+	}()
+
+	if blocking, ok := l.comp.(BlockingStarter); ok && blocking.BlockingStart() {
+		l.mu.Lock()
+		l.running = done
+		l.mu.Unlock()
+		return nil
+	}
+
+	slow := time.NewTimer(legacySlowStart)
+	defer slow.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-slow.C:
+			lib.Log.Warnf("component '%s' is still starting after %s, implement BlockingStarter if its Start blocks", l.comp.Name(), legacySlowStart)
+		}
+	}
+}
+
+// Run 组件实现 Runner 时直接调用；否则等待 BlockingStarter 组件的 Start 返回，
+// 同步启动的 v1 组件则一直运行到 ctx 结束
+func (l *legacyComponent) Run(ctx context.Context) error {
+	if runner, ok := l.comp.(Runner); ok {
+		return runner.Run(ctx)
+	}
+	l.mu.Lock()
+	running := l.running
+	l.running = nil
+	l.mu.Unlock()
+	if running == nil {
+		<-ctx.Done()
+		return nil
+	}
+	select {
+	case err := <-running:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (l *legacyComponent) RestartPolicy() RestartPolicy {
+	if supervised, ok := l.comp.(Supervised); ok {
+		return supervised.RestartPolicy()
+	}
+	return RestartPolicy{}
+}
+
+func (l *legacyComponent) Drain(ctx context.Context) error {
+	if drainer, ok := l.comp.(Drainer); ok {
+		return drainer.Drain(ctx)
+	}
+	return nil
+}
+
+// Stop 组件实现 Stop(ctx) 时优先调用，随后依次调用 Close 与 Destroy，
+// 最后取消嵌入 Base 的组件订阅组中的订阅
+func (l *legacyComponent) Stop(ctx context.Context) error {
+	var err error
+	if stopper, ok := l.comp.(interface{ Stop(context.Context) error }); ok {
+		err = stopper.Stop(ctx)
+	}
+	closeErr := safeCall(l.comp.Name(), "stop", func() error {
+		l.comp.Close()
+		l.comp.Destroy()
+		return nil
+	})
+	if owner, ok := l.comp.(interface{ unsubscribeAll() }); ok {
+		owner.unsubscribeAll()
+	}
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// safeCall 执行组件生命周期函数，并将 panic 转换为错误
+func safeCall(name string, stage string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("component '%s' panic during %s: %v", name, stage, r)
+			lib.Log.Errorf("%v\n%s", err, debug.Stack())
+		}
+	}()
+	return fn()
+}
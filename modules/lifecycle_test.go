@@ -0,0 +1,57 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type slowComponent struct {
+	Base
+	started atomic.Bool
+}
+
+func (c *slowComponent) Name() string { return "slow" }
+
+func (c *slowComponent) Start() error {
+	time.Sleep(300 * time.Millisecond)
+	c.started.Store(true)
+	return nil
+}
+
+type blockingComponent struct {
+	Base
+	stop chan struct{}
+}
+
+func (c *blockingComponent) Name() string        { return "blocking" }
+func (c *blockingComponent) BlockingStart() bool { return true }
+
+func (c *blockingComponent) Start() error {
+	<-c.stop
+	return errors.New("server stopped")
+}
+
+func TestLegacyComponent_StartIsSynchronous(t *testing.T) {
+	comp := &slowComponent{}
+	if err := Adapt(comp).Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !comp.started.Load() {
+		t.Error("Start should wait for a slow v1 Start to return")
+	}
+}
+
+func TestLegacyComponent_BlockingStart(t *testing.T) {
+	comp := &blockingComponent{stop: make(chan struct{})}
+	adapted := Adapt(comp).(*legacyComponent)
+	if err := adapted.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	close(comp.stop)
+	if err := adapted.Run(context.Background()); err == nil || err.Error() != "server stopped" {
+		t.Errorf("Run should return the error of the blocking Start, got %v", err)
+	}
+}
@@ -1,10 +1,16 @@
 package modules
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/gloopai/gloop/lib"
 	"github.com/gloopai/gloop/modules/node"
+	"github.com/gloopai/gloop/servicehub"
 )
 
 // Node 组件
@@ -12,12 +18,29 @@ type Node struct {
 	Base
 	Config NodeOptions
 	Client *node.Client
+	Hub    *servicehub.ServiceHub // 对外提供与远程调用使用的 ServiceHub，默认为单例
+	// Federation 配置了 EventTopics 时在节点之间转发容器事件总线上的事件
+	Federation *node.Federation
+
+	server *http.Server
 }
 
 type NodeOptions struct {
 	NodeID  string
 	Address string
 	Gateway string
+
+	Listen string     // 服务调用入口的监听地址，如 ":7001"，为空时不对其他节点提供服务
+	Token  string     // 节点之间调用服务与转发事件的令牌，配置 Listen 或 EventTopics 时必须设置
+	Peers  []NodePeer // 远程节点，本地不存在的服务转发到远程节点调用
+
+	EventTopics []string // 转发到远程节点的事件模式，如 "order.>"，远程节点需配置 Listen
+}
+
+// NodePeer 远程节点
+type NodePeer struct {
+	NodeID  string
+	Address string // 远程节点服务调用入口地址，如 "http://10.0.0.2:7001"
 }
 
 func NewNode() (*Node, error) {
@@ -50,6 +73,15 @@ func (n *Node) loadOptions() error {
 	return nil
 }
 
+/* 容器配置段：[node] */
+func (n *Node) ConfigSection() string {
+	return n.Name()
+}
+
+func (n *Node) ConfigOptions() any {
+	return &n.Config
+}
+
 func (n *Node) Name() string {
 	return "node"
 }
@@ -59,20 +91,113 @@ func (n *Node) Init() {
 }
 
 func (n *Node) Start() error {
+	if n.Hub == nil {
+		n.Hub = servicehub.GetHubInstance()
+	}
+	if ctx := n.GetContext(); ctx != nil && ctx.Events != nil && len(n.Config.EventTopics) > 0 && n.Federation == nil {
+		federation, err := node.NewFederation(ctx.Events, node.FederationConfig{
+			NodeID: n.Config.NodeID,
+			Topics: n.Config.EventTopics,
+			Token:  n.Config.Token,
+		})
+		if err != nil {
+			return fmt.Errorf("node event federation: %w", err)
+		}
+		n.Federation = federation
+	}
+	if err := n.serveServices(); err != nil {
+		return err
+	}
+	for _, peer := range n.Config.Peers {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := n.Hub.AddPeer(ctx, peer.NodeID, peer.Address, servicehub.WithPeerToken(n.Config.Token)); err != nil {
+			lib.Log.Warnf("[node] %v", err)
+		}
+		cancel()
+		if n.Federation != nil {
+			if err := n.Federation.AddPeer(peer.NodeID, peer.Address); err != nil {
+				return fmt.Errorf("node event federation: %w", err)
+			}
+		}
+	}
+
 	n.Client = node.NewClient(node.ClientConfig{
-		NodeID:  n.Config.NodeID,
-		Address: n.Config.Address,
-		Gateway: n.Config.Gateway,
+		NodeID:   n.Config.NodeID,
+		Address:  n.Config.Address,
+		Gateway:  n.Config.Gateway,
+		Services: n.Hub.Services,
 	})
 	n.Client.Start()
 
 	return nil
 }
+
+/* 在 Listen 地址上提供服务调用与事件转发入口 */
+func (n *Node) serveServices() error {
+	if n.Config.Listen == "" || n.server != nil {
+		return nil
+	}
+	if n.Config.Token == "" {
+		return fmt.Errorf("node listen %s requires a token", n.Config.Listen)
+	}
+	listener, err := net.Listen("tcp", n.Config.Listen)
+	if err != nil {
+		return fmt.Errorf("node listen %s failed: %w", n.Config.Listen, err)
+	}
+	handler := n.Hub.Handler(n.Config.Token)
+	if n.Federation != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/", handler)
+		mux.Handle("/events/", n.Federation.Handler())
+		handler = mux
+	}
+	n.server = &http.Server{Handler: handler}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			lib.Log.Errorf("[node] service endpoint stopped: %v", err)
+		}
+	}(n.server)
+	lib.Log.Infof("[node] serving services on %s", listener.Addr())
+	return nil
+}
+
+/* 健康检查：报告网关是否可达 */
+func (n *Node) HealthCheck(ctx context.Context) error {
+	if n.Client == nil {
+		return fmt.Errorf("node client is not started")
+	}
+	return n.Client.Ping(ctx)
+}
+
+/* 停止心跳与服务调用入口，网关协议未定义注销接口 */
+func (n *Node) Stop(ctx context.Context) error {
+	var errs []error
+	if n.Federation != nil {
+		n.Federation.Close()
+		n.Federation = nil
+	}
+	if n.server != nil {
+		errs = append(errs, n.server.Shutdown(ctx))
+		n.server = nil
+	}
+	if n.Client != nil {
+		n.Client.Stop()
+		n.Client = nil
+	}
+	return errors.Join(errs...)
+}
+
 func (n *Node) printInfo() {
 	infos := make([]string, 0, 7)
 	infos = append(infos, fmt.Sprintf("NodeID: %s", n.Config.NodeID))
 	// infos = append(infos, fmt.Sprintf("Name: %s", s.Name()))
 	infos = append(infos, fmt.Sprintf("Address: %s", n.Config.Address))
+	if n.Config.Listen != "" {
+		infos = append(infos, fmt.Sprintf("Listen: %s", n.Config.Listen))
+	}
+	if len(n.Config.Peers) > 0 {
+		infos = append(infos, fmt.Sprintf("Peers: %d", len(n.Config.Peers)))
+	}
 
 	PrintBoxInfo(n.Name(), infos...)
 }
@@ -1,22 +1,29 @@
 package node
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/servicehub"
 )
 
 // Client
 type Client struct {
 	Config *ClientConfig
 	ticker *time.Ticker
+	stop   chan struct{}
 }
 
 type ClientConfig struct {
 	Gateway string `json:"gateway"`
 	NodeID  string `json:"node_id"`
 	Address string `json:"address"`
+
+	// Services 返回节点提供的服务目录，注册时通告给网关
+	Services func() []servicehub.ServiceInfo `json:"-"`
 }
 
 func NewClient(conf ClientConfig) *Client {
@@ -29,16 +36,27 @@ func (c *Client) Start() {
 	c.Register()
 
 	c.ticker = time.NewTicker(5 * time.Second) // 每 5 秒触发一次
-	go func() {
-		for range c.ticker.C {
-			c.Heartbeat()
+	c.stop = make(chan struct{})
+	go func(ticker *time.Ticker, stop chan struct{}) {
+		for {
+			select {
+			case <-ticker.C:
+				c.Heartbeat()
+			case <-stop:
+				return
+			}
 		}
-	}()
+	}(c.ticker, c.stop)
 }
 
 func (c *Client) Stop() {
 	if c.ticker != nil {
 		c.ticker.Stop()
+		c.ticker = nil
+	}
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
 	}
 }
 
@@ -52,10 +70,14 @@ func (c *Client) do(url string, data interface{}) ([]byte, error) {
 }
 
 func (c *Client) Register() error {
-	body, err := c.do("/nodes/register", map[string]interface{}{
+	data := map[string]interface{}{
 		"NodeID":  c.Config.NodeID,
 		"Address": c.Config.Address,
-	})
+	}
+	if c.Config.Services != nil {
+		data["Services"] = c.Config.Services()
+	}
+	body, err := c.do("/nodes/register", data)
 	if err != nil {
 		lib.Log.Error("Register error:", err)
 	}
@@ -63,6 +85,20 @@ func (c *Client) Register() error {
 	return nil
 }
 
+// Ping 检查网关是否可达，网关返回任意 HTTP 响应即视为可达
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Config.Gateway, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gateway unreachable: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
 func (c *Client) Heartbeat() {
 	// 心跳逻辑
 	// 例如：向 CenterAddress 发送心跳请求
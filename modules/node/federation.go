@@ -0,0 +1,365 @@
+package node
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 节点 A 将 order.> 转发到节点 B，节点 B 在本地事件总线上重新发布
+fed, err := node.NewFederation(eb, node.FederationConfig{
+	NodeID: "node-a",
+	Topics: []string{"order.>"},
+	Token:  "secret",
+})
+if err != nil {
+	return err
+}
+http.Handle("/events/", fed.Handler())
+if err := fed.AddPeer("node-b", "http://10.0.0.3:7001"); err != nil {
+	return err
+}
+defer fed.Close()
+
+// 节点 B 收到的消息 Origin 为 "node-a"，Data 为 JSON，使用 Unmarshal 读取
+eb.Subscribe("order.created", func(msg *events.EventMessage) {
+	var order Order
+	msg.Unmarshal(&order)
+})
+
+*/
+
+const (
+	federationPublishPath = "/events/publish"
+	maxEnvelopeBytes      = 4 << 20 // 接收的单条消息的最大长度
+)
+
+// FederationConfig 事件联邦配置
+type FederationConfig struct {
+	NodeID    string             // 本节点 ID，作为转发消息的 Origin，不能为空
+	Topics    []string           // 转发到远程节点的事件模式，如 "order.>"
+	Token     string             // 节点之间转发事件的令牌，不能为空
+	Timeout   time.Duration      // 单次转发的超时时间，默认 5 秒
+	Retry     events.RetryPolicy // 转发失败时的重试策略，默认最多 5 次，间隔从 200 毫秒开始
+	Workers   int                // 每个远程节点同时转发的消息数量，默认 4
+	QueueSize int                // 每个远程节点等待转发的消息数量上限，默认 1024，超出时 Publish 返回 *events.ErrQueueFull
+	Dedup     int                // 记住的已接收消息数量，用于去重，默认 10000
+}
+
+// Federation 在节点之间转发事件：本节点发布的匹配 Topics 的消息转发到所有远程节点，
+// 远程节点在本地事件总线上重新发布。从远程节点收到的消息不再转发，避免循环；
+// 转发失败时按 Retry 重试，接收方按消息 ID 去重。
+//
+// 事件总线设置了存储（EventBus.UseStore）时，每个远程节点的每个 Topic 使用持久化订阅
+// "federation:<nodeID>:<topic>"，待转发的消息先写入存储，进程重启后重新转发（至少一次），
+// 此时 Workers 与 QueueSize 不生效；未设置存储时待转发的消息只保存在内存中，
+// 远程节点不可用导致队列已满时 Publish 返回 *events.ErrQueueFull，消息写入死信。
+// 重试用尽的消息写入死信，可以使用 EventBus.ReplayDeadLetter 重新转发。
+//
+// 远程节点只能通过 AddPeer 添加（modules.Node 使用配置中的 Peers），网关协议没有节点列表接口，
+// 不会自动发现新节点
+type Federation struct {
+	config FederationConfig
+	bus    *events.EventBus
+	client *http.Client
+	seen   *dedupCache
+
+	mu    sync.Mutex
+	peers map[string]*peer
+}
+
+// peer 向一个远程节点转发的订阅
+type peer struct {
+	sub     *events.Subscription // 未设置存储时的内存订阅
+	durable []string             // 设置了存储时的持久化订阅者名称
+}
+
+// NewFederation 创建事件联邦，NodeID 与 Token 不能为空
+func NewFederation(bus *events.EventBus, config FederationConfig) (*Federation, error) {
+	if config.NodeID == "" {
+		return nil, errors.New("federation requires a node id")
+	}
+	if config.Token == "" {
+		return nil, errors.New("federation requires a token")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Retry.MaxAttempts <= 0 {
+		config.Retry.MaxAttempts = 5
+	}
+	if config.Retry.Backoff <= 0 {
+		config.Retry.Backoff = 200 * time.Millisecond
+	}
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	if config.Dedup <= 0 {
+		config.Dedup = 10000
+	}
+	return &Federation{
+		config: config,
+		bus:    bus,
+		client: &http.Client{Timeout: config.Timeout},
+		seen:   newDedupCache(config.Dedup),
+		peers:  make(map[string]*peer),
+	}, nil
+}
+
+// envelope 节点之间转发的消息
+type envelope struct {
+	ID            string          `json:"id"`
+	Event         string          `json:"event"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Data          json.RawMessage `json:"data"`
+	Key           string          `json:"key,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	ReplyTo       string          `json:"reply_to,omitempty"`
+	Origin        string          `json:"origin"`
+}
+
+// AddPeer 添加远程节点，之后发布的匹配消息转发到该节点；address 为远程节点 Handler 的地址，如 "http://10.0.0.3:7001"。
+// 同一 nodeID 会被替换；保存持久化订阅者失败时返回错误
+func (f *Federation) AddPeer(nodeID, address string) error {
+	address = strings.TrimRight(address, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if old, ok := f.peers[nodeID]; ok {
+		old.stop(f.bus)
+		delete(f.peers, nodeID)
+	}
+	p := &peer{}
+	if f.bus.HasStore() {
+		for _, topic := range f.config.Topics {
+			name := "federation:" + nodeID + ":" + topic
+			err := f.bus.SubscribeDurableE(topic, name, func(msg *events.EventMessage) error {
+				if msg.Origin != "" {
+					return nil
+				}
+				return f.forward(nodeID, address, msg)
+			}, events.WithRetry(f.config.Retry))
+			if err != nil {
+				p.stop(f.bus)
+				return fmt.Errorf("forward events to peer %s: %w", nodeID, err)
+			}
+			p.durable = append(p.durable, name)
+		}
+	} else {
+		p.sub = f.bus.SubscribePatternE(">", func(msg *events.EventMessage) error {
+			return f.forward(nodeID, address, msg)
+		},
+			events.WithName("federation:"+nodeID),
+			events.WithFilter(f.selected),
+			events.WithRetry(f.config.Retry),
+			events.WithPool(events.PoolConfig{Workers: f.config.Workers, QueueSize: f.config.QueueSize, Overflow: events.OverflowError}),
+		)
+	}
+	f.peers[nodeID] = p
+	lib.Log.Infof("[node] forward events %v to peer %s at %s", f.config.Topics, nodeID, address)
+	return nil
+}
+
+// RemovePeer 停止向远程节点转发，并删除存储中该节点尚未转发的消息
+func (f *Federation) RemovePeer(nodeID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.peers[nodeID]
+	if !ok {
+		return
+	}
+	p.stop(f.bus)
+	delete(f.peers, nodeID)
+	for _, name := range p.durable {
+		if err := f.bus.RemoveDurable(context.Background(), name); err != nil {
+			lib.Log.Errorf("[node] remove pending events for peer %s failed: %v", nodeID, err)
+		}
+	}
+}
+
+// Close 停止向所有远程节点转发，已入队的消息仍会转发；
+// 存储中尚未转发的消息保留，再次 AddPeer 时重新转发
+func (f *Federation) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for nodeID, p := range f.peers {
+		p.stop(f.bus)
+		delete(f.peers, nodeID)
+	}
+}
+
+// stop 停止本进程的转发订阅，持久化订阅者仍保留在存储中
+func (p *peer) stop(bus *events.EventBus) {
+	if p.sub != nil {
+		p.sub.Unsubscribe()
+	}
+	for _, name := range p.durable {
+		bus.UnsubscribeDurable(name)
+	}
+}
+
+// selected 只转发本节点发布的、匹配 Topics 的消息
+func (f *Federation) selected(msg *events.EventMessage) bool {
+	if msg.Origin != "" {
+		return false
+	}
+	for _, topic := range f.config.Topics {
+		if events.MatchPattern(topic, msg.Event) {
+			return true
+		}
+	}
+	return false
+}
+
+// forward 将消息发送到远程节点，远程节点返回 2xx 视为成功
+func (f *Federation) forward(nodeID, address string, msg *events.EventMessage) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("encode event %s (%s) failed: %w", msg.Event, msg.ID, err)
+	}
+	body, err := json.Marshal(envelope{
+		ID:            msg.ID,
+		Event:         msg.Event,
+		Timestamp:     msg.Timestamp,
+		Data:          data,
+		Key:           msg.Key,
+		CorrelationID: msg.CorrelationID,
+		ReplyTo:       msg.ReplyTo,
+		Origin:        f.config.NodeID,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, address+federationPublishPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.config.Token)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("forward event %s (%s) to peer %s failed: %w", msg.Event, msg.ID, nodeID, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("forward event %s (%s) to peer %s failed: %s", msg.Event, msg.ID, nodeID, resp.Status)
+	}
+	return nil
+}
+
+// Handler 返回接收远程节点事件的 HTTP 处理器，入口为 /events/publish，
+// 要求请求携带 Authorization: Bearer <token>
+func (f *Federation) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(federationPublishPath, f.servePublish)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(f.config.Token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// servePublish 在本地事件总线上重新发布远程节点的消息，重复的消息直接确认
+func (f *Federation) servePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var env envelope
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEnvelopeBytes)).Decode(&env); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "event too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if env.ID == "" || env.Event == "" || env.Origin == "" {
+		http.Error(w, "event id, name and origin are required", http.StatusBadRequest)
+		return
+	}
+	if env.Origin == f.config.NodeID {
+		// 配置错误导致消息转发回来源节点
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !f.seen.add(env.ID) {
+		lib.Log.Debugf("[node] drop duplicate event %s (%s) from %s", env.Event, env.ID, env.Origin)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	err := f.bus.PublishRemote(&events.EventMessage{
+		ID:            env.ID,
+		Event:         env.Event,
+		Timestamp:     env.Timestamp,
+		Data:          env.Data,
+		Key:           env.Key,
+		CorrelationID: env.CorrelationID,
+		ReplyTo:       env.ReplyTo,
+		Origin:        env.Origin,
+	})
+	if err != nil {
+		// 未能入队，允许来源节点重试
+		f.seen.remove(env.ID)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dedupCache 记住最近收到的消息 ID，超过容量时忘记最早的
+type dedupCache struct {
+	mu    sync.Mutex
+	size  int
+	ids   map[string]*list.Element
+	order *list.List // 按加入顺序排列的 ID，最早的在前
+}
+
+func newDedupCache(size int) *dedupCache {
+	return &dedupCache{size: size, ids: make(map[string]*list.Element, size), order: list.New()}
+}
+
+// add 记住 id，已存在时返回 false
+func (c *dedupCache) add(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.ids[id]; ok {
+		return false
+	}
+	c.ids[id] = c.order.PushBack(id)
+	if c.order.Len() > c.size {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.ids, oldest.Value.(string))
+	}
+	return true
+}
+
+// remove 忘记 id，之后再次收到时不视为重复
+func (c *dedupCache) remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.ids[id]; ok {
+		c.order.Remove(elem)
+		delete(c.ids, id)
+	}
+}
@@ -0,0 +1,328 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gloopai/gloop/events"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestStore(t *testing.T, path string) *events.SQLStore {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	store, err := events.NewSQLStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+type order struct {
+	Id int
+}
+
+// testNode 一个带事件联邦的本地节点
+type testNode struct {
+	bus    *events.EventBus
+	fed    *Federation
+	server *httptest.Server
+}
+
+func newTestNode(t *testing.T, id string, wrap func(http.Handler) http.Handler) *testNode {
+	bus := events.NewEventBus()
+	fed, err := NewFederation(bus, FederationConfig{
+		NodeID: id,
+		Topics: []string{"order.>"},
+		Token:  "secret",
+		Retry:  events.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := fed.Handler()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		fed.Close()
+		server.Close()
+	})
+	return &testNode{bus: bus, fed: fed, server: server}
+}
+
+// collect 记录节点收到的 order 事件
+func (n *testNode) collect() (received func() []*events.EventMessage) {
+	var mu sync.Mutex
+	var msgs []*events.EventMessage
+	n.bus.SubscribePattern("order.>", func(msg *events.EventMessage) {
+		mu.Lock()
+		msgs = append(msgs, msg)
+		mu.Unlock()
+	})
+	return func() []*events.EventMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*events.EventMessage(nil), msgs...)
+	}
+}
+
+// drain 转发与重新发布在两个节点之间交替进行，多次等待直到都没有进行中的处理器
+func drain(t *testing.T, nodes ...*testNode) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		for _, n := range nodes {
+			if err := n.bus.Drain(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestFederation_TwoNodes(t *testing.T) {
+	a := newTestNode(t, "node-a", nil)
+	b := newTestNode(t, "node-b", nil)
+	if err := a.fed.AddPeer("node-b", b.server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.fed.AddPeer("node-a", a.server.URL); err != nil {
+		t.Fatal(err)
+	}
+	receivedA, receivedB := a.collect(), b.collect()
+	var users int32
+	b.bus.Subscribe("user.created", func(msg *events.EventMessage) { atomic.AddInt32(&users, 1) })
+
+	a.bus.Publish("order.created", order{Id: 1}, events.WithPartitionKey("1"))
+	a.bus.Publish("user.created", nil)
+	drain(t, a, b)
+
+	gotA, gotB := receivedA(), receivedB()
+	if len(gotA) != 1 || len(gotB) != 1 {
+		t.Fatalf("each node should receive the event once, got a=%d b=%d", len(gotA), len(gotB))
+	}
+	msg := gotB[0]
+	var o order
+	if err := msg.Unmarshal(&o); err != nil || o.Id != 1 {
+		t.Errorf("unexpected data %+v, %v", o, err)
+	}
+	if msg.Origin != "node-a" || msg.ID != gotA[0].ID || msg.Key != "1" || gotA[0].Origin != "" {
+		t.Errorf("unexpected forwarded message: %+v", msg)
+	}
+	if atomic.LoadInt32(&users) != 0 {
+		t.Error("events outside the configured topics should not be forwarded")
+	}
+
+	// 从 B 发布的消息同样转发到 A，且不会再转发回 B
+	b.bus.Publish("order.paid", order{Id: 1})
+	drain(t, a, b)
+	if len(receivedA()) != 2 || len(receivedB()) != 2 {
+		t.Errorf("expected 2 events on each node, got a=%d b=%d", len(receivedA()), len(receivedB()))
+	}
+}
+
+func TestFederation_RetryAndDedup(t *testing.T) {
+	var calls int32
+	// 第一次请求已处理但确认丢失，来源节点重试后由接收方去重
+	b := newTestNode(t, "node-b", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				http.Error(w, "ack lost", http.StatusBadGateway)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	a := newTestNode(t, "node-a", nil)
+	if err := a.fed.AddPeer("node-b", b.server.URL); err != nil {
+		t.Fatal(err)
+	}
+	receivedB := b.collect()
+
+	a.bus.Publish("order.created", order{Id: 2})
+	drain(t, a, b)
+	if got := receivedB(); len(got) != 1 || got[0].Origin != "node-a" {
+		t.Errorf("expected exactly one delivery after retry, got %d", len(got))
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("expected the forward to be retried once, got %d calls", calls)
+	}
+}
+
+func TestFederation_RejectsUnauthorized(t *testing.T) {
+	b := newTestNode(t, "node-b", nil)
+	resp, err := http.Post(b.server.URL+federationPublishPath, "application/json", bytes.NewReader([]byte(`{}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestFederation_DeadPeerRejectsInsteadOfBlocking(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer hung.Close()
+	defer close(release)
+
+	bus := events.NewEventBus()
+	bus.UseDeadLetters(events.NewMemoryDeadLetters())
+	fed, err := NewFederation(bus, FederationConfig{NodeID: "node-a", Topics: []string{"order.>"}, Token: "secret", Workers: 1, QueueSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fed.Close()
+	if err := fed.AddPeer("node-b", hung.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	var rejected atomic.Int32
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			var full *events.ErrQueueFull
+			if err := bus.Publish("order.created", order{Id: i}, events.WithPartitionKey("1")); errors.As(err, &full) {
+				rejected.Add(1)
+			}
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publish should not block on an unavailable peer")
+	}
+	// 最多 1 条正在转发、2 条在队列中，其余被拒绝，Publish 返回错误并写入死信
+	if rejected.Load() < 7 {
+		t.Errorf("expected at least 7 publishes to report a full queue, got %d", rejected.Load())
+	}
+	letters, _ := bus.DeadLetters(context.Background(), events.DeadLetterFilter{Subscriber: "federation:node-b"})
+	if len(letters) != int(rejected.Load()) {
+		t.Errorf("expected every rejected event in dead letters, got %d of %d", len(letters), rejected.Load())
+	}
+}
+
+func TestFederation_DurableForwardSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	// 远程节点不可用时消息保留在存储中
+	bus := events.NewEventBus()
+	if err := bus.UseStore(context.Background(), openTestStore(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	config := FederationConfig{NodeID: "node-a", Topics: []string{"order.>"}, Token: "secret", Retry: events.RetryPolicy{MaxAttempts: 1}}
+	fed, err := NewFederation(bus, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fed.AddPeer("node-b", down.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish("order.created", order{Id: 3}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := bus.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fed.Close()
+	bus.Close()
+
+	// 重启后远程节点恢复，未转发的消息重新转发
+	b := newTestNode(t, "node-b", nil)
+	receivedB := b.collect()
+	bus = events.NewEventBus()
+	if err := bus.UseStore(context.Background(), openTestStore(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	if fed, err = NewFederation(bus, config); err != nil {
+		t.Fatal(err)
+	}
+	defer fed.Close()
+	if err := fed.AddPeer("node-b", b.server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	drain(t, b)
+	got := receivedB()
+	if len(got) != 1 || got[0].Origin != "node-a" {
+		t.Fatalf("expected the pending event to be forwarded after restart, got %d", len(got))
+	}
+	var o order
+	if err := got[0].Unmarshal(&o); err != nil || o.Id != 3 {
+		t.Errorf("unexpected data %+v, %v", o, err)
+	}
+}
+
+func TestFederation_RequiresNodeIDAndToken(t *testing.T) {
+	bus := events.NewEventBus()
+	if _, err := NewFederation(bus, FederationConfig{Token: "secret"}); err == nil {
+		t.Error("expected an error without node id")
+	}
+	if _, err := NewFederation(bus, FederationConfig{NodeID: "node-a"}); err == nil {
+		t.Error("expected an error without token")
+	}
+}
+
+func TestFederation_RejectsOversizedEvent(t *testing.T) {
+	b := newTestNode(t, "node-b", nil)
+	body := `{"id":"1","event":"order.created","origin":"node-a","data":"` + strings.Repeat("x", maxEnvelopeBytes) + `"}`
+	req, _ := http.NewRequest(http.MethodPost, b.server.URL+federationPublishPath, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", resp.StatusCode)
+	}
+}
+
+func TestDedupCache_RemoveForgetsOrder(t *testing.T) {
+	c := newDedupCache(2)
+	c.add("a")
+	c.remove("a")
+	if !c.add("a") {
+		t.Fatal("removed id should be accepted again")
+	}
+	c.add("b")
+	// 删除的旧记录不应占用容量，使重新加入的 a 被提前淘汰
+	if c.add("a") {
+		t.Error("expected a to be remembered")
+	}
+	if c.order.Len() != 2 {
+		t.Errorf("expected 2 ids in order, got %d", c.order.Len())
+	}
+}
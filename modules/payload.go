@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,12 +15,27 @@ type RequestPayload struct {
 	Auth    RequestAuth `json:"auth"`
 	Command string      `json:"command"`
 	Data    interface{} `json:"data"`
+
+	ctx context.Context
 }
 type RequestAuth struct {
 	UserId   int64  `json:"user_id"`
 	Username string `json:"username"`
 }
 
+// Context 返回请求的 context，携带调用方身份与追踪 ID，可直接用于 servicehub.CallContext
+func (d *RequestPayload) Context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// SetContext 设置请求的 context
+func (d *RequestPayload) SetContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
 // Data 反序列化
 func (d *RequestPayload) Unmarshal(v interface{}) error {
 	return lib.Convert.InterfaceToStruct(d.Data, &v)
@@ -16,14 +16,15 @@ type SiteCert struct {
 
 // SiteConfig 保存 Site 的配置
 type SiteOptions struct {
-	Id             string   `json:"id"`               // 站点 ID
-	Port           int      `json:"port"`             // 端口号
-	UseHTTPS       bool     `json:"use_https"`        // 是否使用 HTTPS
-	Cert           SiteCert `json:"cert"`             // 证书配置
-	BaseRoot       string   `json:"base_root"`        // 基础目录
-	UseEmbed       bool     `json:"use_embed"`        // 是否使用嵌入文件
-	EmbedFiles     embed.FS `json:"embed_files"`      // 嵌入文件系统
-	ForceIndexHTML bool     `json:"force_index_html"` // 是否强制使用 index.html
+	Id             string   `json:"id"`                              // 站点 ID
+	Name           string   `json:"name"`                            // 站点名称，对应容器配置段 [site.<name>]
+	Port           int      `json:"port" validate:"gte=0,lte=65535"` // 端口号
+	UseHTTPS       bool     `json:"use_https"`                       // 是否使用 HTTPS
+	Cert           SiteCert `json:"cert"`                            // 证书配置
+	BaseRoot       string   `json:"base_root"`                       // 基础目录
+	UseEmbed       bool     `json:"use_embed"`                       // 是否使用嵌入文件
+	EmbedFiles     embed.FS `json:"embed_files" toml:"-"`            // 嵌入文件系统
+	ForceIndexHTML bool     `json:"force_index_html"`                // 是否强制使用 index.html
 
 	// 在 SiteConfig 中添加 StaticFileCacheTTL 配置项
 	StaticFileCacheTTL time.Duration `json:"static_file_cache_ttl"`
@@ -1,16 +1,22 @@
 package site
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/health"
 	"github.com/gloopai/gloop/lib"
 	"github.com/gloopai/gloop/modules"
 	"github.com/gloopai/gloop/modules/auth"
 	"github.com/gloopai/gloop/modules/db"
+	"github.com/gloopai/gloop/servicehub"
 )
 
 // Site 代表一个具有可配置域和设置的 Web 服务器
@@ -19,11 +25,20 @@ type Site struct {
 	Config SiteOptions    // 站点配置
 	mux    *http.ServeMux // HTTP 路由器
 
+	lock         sync.Mutex   // 保护 server、serveErr 与可热加载的 Config.CrossOrigin、Config.StaticFileCacheTTL
+	server       *http.Server // HTTP 服务器，Start 后有效
+	serveErr     chan error   // 服务器运行期间的错误，由 Run 上报给容器
+	staticRouted bool         // 静态文件路由是否已注册，重启时避免重复注册
+	crossOrigin  atomic.Bool
+	static       atomic.Pointer[StaticFileHandler]
+
 	// 在 Site 结构中添加 RouteCommandMap
 	RouteCommandMap *RouteCommandManager
 	Auth            *auth.Auth
 	events          *events.EventBus // 事件总线
 	DbService       *db.DbService
+
+	streams []*EventStream // AddEventStream 注册的事件流，停机时断开客户端
 }
 
 // 初始化日志记录器
@@ -55,6 +70,77 @@ func (s *Site) Init() {
 	}
 }
 
+/* 容器配置段：[site.<name>]，未设置名称时为 [site] */
+func (s *Site) ConfigSection() string {
+	if s.Config.Name == "" {
+		return s.Name()
+	}
+	return s.Name() + "." + s.Config.Name
+}
+
+func (s *Site) ConfigOptions() any {
+	return &s.Config
+}
+
+/* 通过 UseAuth、UseDbService 注入的组件添加到容器时先于站点启动，未添加时由使用者自行初始化 */
+func (s *Site) DependsOn() []modules.Dependency {
+	deps := make([]modules.Dependency, 0, 2)
+	if s.Auth != nil {
+		deps = append(deps, modules.OptionalDependOnType[*auth.Auth]())
+	}
+	if s.DbService != nil {
+		deps = append(deps, modules.OptionalDependOnType[*db.DbService]())
+	}
+	return deps
+}
+
+/* 健康检查：确认站点正在监听端口并能接受连接 */
+func (s *Site) HealthCheck(ctx context.Context) error {
+	s.lock.Lock()
+	server := s.server
+	s.lock.Unlock()
+	if server == nil {
+		return fmt.Errorf("站点未启动 (端口: %d)", s.Config.Port)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", s.Config.Port))
+	if err != nil {
+		return fmt.Errorf("站点端口不可用 (端口: %d): %w", s.Config.Port, err)
+	}
+	return conn.Close()
+}
+
+/* 挂载 /healthz、/readyz、/livez 健康检查路由 */
+func (s *Site) UseHealth(registry *health.Registry) {
+	s.AddRoute("/healthz", registry.Handler(0))
+	s.AddRoute("/readyz", registry.Handler(health.Readiness))
+	s.AddRoute("/livez", registry.Handler(health.Liveness))
+}
+
+/* 停止接收新连接，并等待处理中的请求完成 */
+func (s *Site) Drain(ctx context.Context) error {
+	s.lock.Lock()
+	server := s.server
+	s.server = nil
+	s.lock.Unlock()
+	if server == nil {
+		return nil
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("站点停机失败 (端口: %d): %w", s.Config.Port, err)
+	}
+	return nil
+}
+
+/* 停止站点，未经 Drain 时同样会等待处理中的请求；事件流停止推送，重新启动时恢复 */
+func (s *Site) Stop(ctx context.Context) error {
+	err := s.Drain(ctx)
+	for _, stream := range s.streams {
+		stream.Close()
+	}
+	return err
+}
+
 func (s *Site) Close() {}
 
 func (s *Site) Destory() {}
@@ -85,10 +171,25 @@ func (s *Site) Start() error {
 		s.mux = http.NewServeMux()
 	}
 
-	if s.Config.UseEmbed {
-		// 在 Start 方法中增加跨域支持
-		if s.Config.CrossOrigin {
-			s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	crossOrigin, ttl := s.Config.CrossOrigin, s.Config.StaticFileCacheTTL
+	s.lock.Unlock()
+	s.crossOrigin.Store(crossOrigin)
+	if s.Config.UseEmbed && !s.staticRouted {
+		s.staticRouted = true
+		staticFileHandler := NewStaticFileHandler(StaticFileHandlerConfig{
+			TTL:            ttl,
+			BaseRoot:       s.Config.BaseRoot,
+			UseEmbed:       s.Config.UseEmbed,
+			EmbedFS:        s.Config.EmbedFiles,
+			ForceIndexHTML: s.Config.ForceIndexHTML,
+		})
+		staticFileHandler.StartCacheCleaner()
+		s.static.Store(staticFileHandler)
+
+		// 跨域支持，CrossOrigin 可通过配置热加载修改
+		s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if s.crossOrigin.Load() {
 				w.Header().Set("Access-Control-Allow-Origin", "*")
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
@@ -96,13 +197,9 @@ func (s *Site) Start() error {
 					w.WriteHeader(http.StatusOK)
 					return
 				}
-				s.serveStaticFiles(w, r)
-			})
-		} else {
-			s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-				s.serveStaticFiles(w, r)
-			})
-		}
+			}
+			s.serveStaticFiles(w, r)
+		})
 	}
 
 	// 优化 HTTP 服务器配置
@@ -127,16 +224,37 @@ func (s *Site) Start() error {
 		server.TLSConfig = &tls.Config{
 			Certificates: []tls.Certificate{cert},
 		}
+	}
+
+	// 事件流是长连接，停机时主动断开，否则 Shutdown 会一直等待；重新启动时恢复 Stop 停止的推送
+	for _, stream := range s.streams {
+		stream.subscribe()
+	}
+	server.RegisterOnShutdown(s.disconnectStreams)
+
+	// 同步监听端口，端口被占用等错误直接返回给容器
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return fmt.Errorf("监听端口失败 (端口: %d): %v", s.Config.Port, err)
+	}
 
+	serveErr := make(chan error, 1)
+	s.lock.Lock()
+	s.server = server
+	s.serveErr = serveErr
+	s.lock.Unlock()
+	if s.Config.UseHTTPS {
 		go func() {
-			if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			if err := server.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
 				fmt.Printf("HTTPS 服务器错误: %v\n", err)
+				serveErr <- fmt.Errorf("HTTPS 服务器错误 (端口: %d): %w", s.Config.Port, err)
 			}
 		}()
 	} else {
 		go func() {
-			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 				fmt.Printf("HTTP 服务器错误: %v\n", err)
+				serveErr <- fmt.Errorf("HTTP 服务器错误 (端口: %d): %w", s.Config.Port, err)
 			}
 		}()
 	}
@@ -144,20 +262,69 @@ func (s *Site) Start() error {
 	return nil
 }
 
-// 替换 serveStaticFiles 调用为使用 StaticFileHandler
+/* 等待服务器运行期间的错误，由容器监管并按重启策略重启站点 */
+func (s *Site) Run(ctx context.Context) error {
+	s.lock.Lock()
+	serveErr := s.serveErr
+	s.lock.Unlock()
+	if serveErr == nil {
+		<-ctx.Done()
+		return nil
+	}
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+/* 站点默认在服务器异常退出时重启 */
+func (s *Site) RestartPolicy() modules.RestartPolicy {
+	return modules.RestartPolicy{
+		Mode:        modules.RestartOnFailure,
+		MaxRestarts: 5,
+	}
+}
+
+// 使用 Start 时创建的 StaticFileHandler 提供静态文件，缓存在请求之间共享
 func (s *Site) serveStaticFiles(w http.ResponseWriter, r *http.Request) {
-	config := StaticFileHandlerConfig{
-		TTL:            s.Config.StaticFileCacheTTL,
-		BaseRoot:       s.Config.BaseRoot,
-		UseEmbed:       s.Config.UseEmbed,
-		EmbedFS:        s.Config.EmbedFiles,
-		ForceIndexHTML: s.Config.ForceIndexHTML,
-	}
-	staticFileHandler := NewStaticFileHandler(config)
-	staticFileHandler.StartCacheCleaner()
+	staticFileHandler := s.static.Load()
+	if staticFileHandler == nil {
+		http.NotFound(w, r)
+		return
+	}
 	staticFileHandler.ServeStaticFile(w, r)
 }
 
+/* 配置热加载：跨域与静态文件缓存时间立即生效，端口、HTTPS、目录等需重启后生效 */
+func (s *Site) Reconfigure(old, new any) error {
+	prev, ok := old.(*SiteOptions)
+	next, ok2 := new.(*SiteOptions)
+	if !ok || !ok2 {
+		return fmt.Errorf("unexpected site options type %T", new)
+	}
+	if next.Port != prev.Port || next.UseHTTPS != prev.UseHTTPS || next.Cert != prev.Cert ||
+		next.BaseRoot != prev.BaseRoot || next.UseEmbed != prev.UseEmbed || next.ForceIndexHTML != prev.ForceIndexHTML {
+		lib.Log.Warnf("[%s] port, https, cert and static file settings take effect after restart", s.ConfigSection())
+	}
+
+	s.lock.Lock()
+	s.Config.CrossOrigin = next.CrossOrigin
+	if next.StaticFileCacheTTL > 0 {
+		s.Config.StaticFileCacheTTL = next.StaticFileCacheTTL
+	}
+	crossOrigin, ttl := s.Config.CrossOrigin, s.Config.StaticFileCacheTTL
+	s.lock.Unlock()
+
+	s.crossOrigin.Store(crossOrigin)
+	if staticFileHandler := s.static.Load(); staticFileHandler != nil {
+		staticFileHandler.SetTTL(ttl)
+	}
+	lib.Log.Infof("[%s] reconfigured: CrossOrigin=%t StaticFileCacheTTL=%s", s.ConfigSection(), crossOrigin, ttl)
+	return nil
+}
+
 // 注册一个普通路由
 func (s *Site) AddRoute(pattern string, handlerFunc http.HandlerFunc) {
 	defer func() {
@@ -213,9 +380,16 @@ func (s *Site) handlePayloadRequest(w http.ResponseWriter, r *http.Request, patt
 		return
 	}
 
+	// 调用方身份与追踪 ID 随 context 传递给跨模块的服务调用
+	ctx := r.Context()
+	if traceID := r.Header.Get("X-Trace-Id"); traceID != "" {
+		ctx = servicehub.WithTraceID(ctx, traceID)
+	}
 	if auth != nil {
 		payload.Auth = *auth
+		ctx = servicehub.WithCaller(ctx, servicehub.Caller{UserId: auth.UserId, Username: auth.Username})
 	}
+	payload.SetContext(ctx)
 
 	// 根据 Command 执行对应的处理函数
 	key := fmt.Sprintf("%s:%s", pattern, payload.Command)
@@ -294,3 +468,9 @@ func (s *Site) UseDbService(db *db.DbService) {
 func (s *Site) UseEventBus(events *events.EventBus) {
 	s.events = events
 }
+
+func (s *Site) disconnectStreams() {
+	for _, stream := range s.streams {
+		stream.disconnect()
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,7 +18,7 @@ import (
 type StaticFileHandler struct {
 	cache          sync.Map
 	cacheMutex     sync.Mutex
-	cacheTTL       time.Duration
+	cacheTTL       atomic.Int64 // 缓存时间，支持运行时修改
 	embedFS        embed.FS
 	BaseRoot       string
 	useEmbed       bool
@@ -34,13 +35,24 @@ type StaticFileHandlerConfig struct {
 
 // NewStaticFileHandler 创建一个新的静态文件处理器
 func NewStaticFileHandler(config StaticFileHandlerConfig) *StaticFileHandler {
-	return &StaticFileHandler{
-		cacheTTL:       config.TTL,
+	h := &StaticFileHandler{
 		embedFS:        config.EmbedFS,
 		BaseRoot:       config.BaseRoot,
 		useEmbed:       config.UseEmbed,
 		forceIndexHTML: config.ForceIndexHTML,
 	}
+	h.SetTTL(config.TTL)
+	return h
+}
+
+// SetTTL 修改缓存时间，已缓存的文件按新的时间判断是否过期
+func (h *StaticFileHandler) SetTTL(ttl time.Duration) {
+	h.cacheTTL.Store(int64(ttl))
+}
+
+// TTL 返回当前缓存时间
+func (h *StaticFileHandler) TTL() time.Duration {
+	return time.Duration(h.cacheTTL.Load())
 }
 
 // ServeStaticFile 提供静态文件服务
@@ -51,7 +63,7 @@ func (h *StaticFileHandler) ServeStaticFile(w http.ResponseWriter, r *http.Reque
 	requestedPath := filepath.Join(h.BaseRoot, r.URL.Path)
 	// 检查缓存
 	if cachedContent, ok := h.cache.Load(requestedPath); ok {
-		if content, valid := cachedContent.(cachedFile); valid && time.Since(content.timestamp) < h.cacheTTL {
+		if content, valid := cachedContent.(cachedFile); valid && time.Since(content.timestamp) < h.TTL() {
 			http.ServeContent(w, r, requestedPath, time.Now(), bytes.NewReader(content.data))
 			return
 		}
@@ -114,10 +126,14 @@ type cachedFile struct {
 func (h *StaticFileHandler) StartCacheCleaner() {
 	go func() {
 		for {
-			time.Sleep(h.cacheTTL)
+			ttl := h.TTL()
+			if ttl <= 0 {
+				ttl = time.Minute
+			}
+			time.Sleep(ttl)
 			h.cacheMutex.Lock()
 			h.cache.Range(func(key, value interface{}) bool {
-				if content, valid := value.(cachedFile); valid && time.Since(content.timestamp) >= h.cacheTTL {
+				if content, valid := value.(cachedFile); valid && time.Since(content.timestamp) >= h.TTL() {
 					h.cache.Delete(key)
 				}
 				return true
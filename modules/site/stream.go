@@ -0,0 +1,430 @@
+package site
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules"
+)
+
+/**
+
+// 将 order.> 推送到浏览器，每个用户只收到自己的订单
+site.UseEventBus(eb)
+site.UseAuth(authModule)
+stream, err := site.AddEventStream("/events", site.EventStreamOptions{
+	Topics: []string{"order.>"},
+	Filter: func(auth modules.RequestAuth, msg *events.EventMessage) bool {
+		order, ok := msg.Data.(Order)
+		return ok && order.UserId == auth.UserId
+	},
+})
+defer stream.Close()
+
+// 浏览器使用 SSE，断线后 EventSource 自动携带 Last-Event-ID 续传
+const source = new EventSource("/events?token=" + token)
+source.onmessage = e => console.log(JSON.parse(e.data)) // {id, message_id, event, timestamp, data}
+
+// 或使用 WebSocket，续传时通过 last_event_id 参数传入最后收到的 id；
+// 页面与站点不同源时需在 AllowedOrigins 中添加页面的来源
+const ws = new WebSocket("wss://host/events?token=" + token + "&last_event_id=" + lastId)
+
+*/
+
+// EventStreamOptions 事件流配置
+type EventStreamOptions struct {
+	Topics    []string                                                      // 推送的事件模式，如 "order.>"
+	Filter    func(auth modules.RequestAuth, msg *events.EventMessage) bool // 按用户过滤，为空时推送所有匹配的事件
+	Buffer    int                                                           // 保留最近的消息数量，用于断线续传，默认 1000
+	Heartbeat time.Duration                                                 // 心跳间隔，默认 15 秒，每次心跳重新验证令牌，令牌过期时断开
+
+	// AllowedOrigins 允许建立 WebSocket 连接的页面来源，如 "https://app.example.com"，"*" 允许所有来源；
+	// 为空时只允许与请求 Host 相同的来源，没有 Origin 请求头的非浏览器客户端不受限制
+	AllowedOrigins []string
+}
+
+// EventStream 将事件总线上的事件通过 SSE 或 WebSocket 推送到浏览器。
+// 每条消息分配流内递增的 id，最近的消息保留在环形缓冲区中，客户端断线后按 id 续传；
+// 处理不过来的客户端会被断开，重连后从缓冲区补齐
+type EventStream struct {
+	options EventStreamOptions
+	name    string
+	bus     *events.EventBus
+
+	mu      sync.Mutex
+	sub     *events.Subscription // Close 后为空，站点重新启动时重新订阅
+	seq     uint64
+	ring    []streamEntry // 环形缓冲区，next 为下一个写入位置
+	next    int
+	count   int
+	clients map[*streamClient]struct{}
+}
+
+// streamEntry 缓冲区中的一条消息，data 为推送给客户端的 JSON
+type streamEntry struct {
+	id   uint64
+	msg  *events.EventMessage
+	data []byte
+}
+
+// streamEvent 推送给客户端的消息
+type streamEvent struct {
+	Id        uint64    `json:"id"` // 流内序号，用于断线续传
+	MessageId string    `json:"message_id"`
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// streamClient 一个已连接的客户端
+type streamClient struct {
+	auth    modules.RequestAuth
+	verify  func() error // 重新验证连接时的令牌
+	entries chan streamEntry
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (c *streamClient) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// AddEventStream 注册事件流路由，同一路由支持 SSE 与 WebSocket。
+// 连接需携带 Auth 签发的令牌（请求头或 token 参数），需先调用 UseAuth 与 UseEventBus；
+// 站点停止时事件流停止推送，重新启动时恢复
+func (s *Site) AddEventStream(pattern string, options EventStreamOptions) (*EventStream, error) {
+	bus := s.events
+	if bus == nil {
+		if ctx := s.GetContext(); ctx != nil {
+			bus = ctx.Events
+		}
+	}
+	if bus == nil {
+		return nil, fmt.Errorf("event stream %s requires an event bus, call UseEventBus first", pattern)
+	}
+	if len(options.Topics) == 0 {
+		return nil, fmt.Errorf("event stream %s has no topics", pattern)
+	}
+	if options.Buffer <= 0 {
+		options.Buffer = 1000
+	}
+	if options.Heartbeat <= 0 {
+		options.Heartbeat = 15 * time.Second
+	}
+
+	stream := &EventStream{
+		options: options,
+		name:    "site.stream:" + pattern,
+		bus:     bus,
+		ring:    make([]streamEntry, options.Buffer),
+		clients: make(map[*streamClient]struct{}),
+	}
+	stream.subscribe()
+	s.streams = append(s.streams, stream)
+
+	s.AddRoute(pattern, func(w http.ResponseWriter, r *http.Request) {
+		c, status, err := s.streamAuth(r)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		lastID, resume := lastEventID(r)
+		if isWebSocket(r) {
+			if !allowedOrigin(r, options.AllowedOrigins) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			stream.serveWebSocket(w, r, c, lastID, resume)
+			return
+		}
+		stream.serveSSE(w, r, c, lastID, resume)
+	})
+	return stream, nil
+}
+
+// streamAuth 验证连接的令牌，浏览器的 EventSource 与 WebSocket 无法设置请求头，也可通过 token 参数传入；
+// 返回的客户端在每次心跳时重新验证该令牌
+func (s *Site) streamAuth(r *http.Request) (*streamClient, int, error) {
+	if s.Auth == nil || s.Auth.JWTManager == nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("auth module not initialized")
+	}
+	token := r.Header.Get(s.Auth.Authorization())
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return nil, http.StatusUnauthorized, fmt.Errorf("missing token")
+	}
+	manager := s.Auth.JWTManager
+	auth, err := manager.VerifyToken(token)
+	if err != nil {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid token: %w", err)
+	}
+	if auth.UserId == 0 {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid token")
+	}
+	return &streamClient{
+		auth: auth,
+		verify: func() error {
+			_, err := manager.VerifyToken(token)
+			return err
+		},
+		entries: make(chan streamEntry, 256),
+		done:    make(chan struct{}),
+	}, http.StatusOK, nil
+}
+
+// lastEventID 读取客户端最后收到的 id，SSE 重连时由浏览器通过 Last-Event-ID 携带
+func lastEventID(r *http.Request) (uint64, bool) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	return id, err == nil
+}
+
+// subscribe 订阅事件总线，已订阅时不做任何操作；单个工作者按发布顺序分配 id
+func (es *EventStream) subscribe() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.sub != nil {
+		return
+	}
+	es.sub = es.bus.SubscribePattern(">", es.publish,
+		events.WithName(es.name),
+		events.WithFilter(es.selected),
+		events.WithPool(events.PoolConfig{Workers: 1, QueueSize: es.options.Buffer}),
+	)
+}
+
+// Close 停止推送并断开所有客户端
+func (es *EventStream) Close() {
+	es.mu.Lock()
+	sub := es.sub
+	es.sub = nil
+	es.mu.Unlock()
+	if sub != nil {
+		sub.Unsubscribe()
+	}
+	es.disconnect()
+}
+
+// Clients 返回已连接的客户端数量
+func (es *EventStream) Clients() int {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return len(es.clients)
+}
+
+// selected 只推送匹配 Topics 的消息
+func (es *EventStream) selected(msg *events.EventMessage) bool {
+	for _, topic := range es.options.Topics {
+		if events.MatchPattern(topic, msg.Event) {
+			return true
+		}
+	}
+	return false
+}
+
+// publish 为消息分配 id，写入缓冲区并交给所有客户端，客户端队列已满时断开该客户端
+func (es *EventStream) publish(msg *events.EventMessage) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	id := es.seq + 1
+	data, err := json.Marshal(streamEvent{Id: id, MessageId: msg.ID, Event: msg.Event, Timestamp: msg.Timestamp, Data: msg.Data})
+	if err != nil {
+		lib.Log.Errorf("[site] encode event %s (%s) for stream failed: %v", msg.Event, msg.ID, err)
+		return
+	}
+	es.seq = id
+	entry := streamEntry{id: id, msg: msg, data: data}
+	es.ring[es.next] = entry
+	es.next = (es.next + 1) % len(es.ring)
+	if es.count < len(es.ring) {
+		es.count++
+	}
+	for c := range es.clients {
+		select {
+		case c.entries <- entry:
+		default:
+			lib.Log.Warnf("[site] event stream client %d is too slow, disconnect", c.auth.UserId)
+			delete(es.clients, c)
+			c.close()
+		}
+	}
+}
+
+// attach 注册客户端，resume 为 true 时返回缓冲区中 id 大于 lastID 的消息
+func (es *EventStream) attach(c *streamClient, lastID uint64, resume bool) []streamEntry {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	var backlog []streamEntry
+	if resume {
+		start := es.next - es.count
+		for i := 0; i < es.count; i++ {
+			entry := es.ring[(start+i+len(es.ring))%len(es.ring)]
+			if entry.id > lastID {
+				backlog = append(backlog, entry)
+			}
+		}
+	}
+	es.clients[c] = struct{}{}
+	return backlog
+}
+
+func (es *EventStream) detach(c *streamClient) {
+	es.mu.Lock()
+	delete(es.clients, c)
+	es.mu.Unlock()
+	c.close()
+}
+
+// disconnect 断开所有客户端，站点停机时调用；客户端重连后按 id 续传
+func (es *EventStream) disconnect() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	for c := range es.clients {
+		delete(es.clients, c)
+		c.close()
+	}
+}
+
+// visible 判断消息是否推送给该客户端
+func (es *EventStream) visible(c *streamClient, entry streamEntry) (ok bool) {
+	if es.options.Filter == nil {
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			lib.Log.Errorf("[site] event stream filter panic on %s (%s): %v", entry.msg.Event, entry.msg.ID, r)
+			ok = false
+		}
+	}()
+	return es.options.Filter(c.auth, entry.msg)
+}
+
+// serveSSE 以 text/event-stream 推送消息
+func (es *EventStream) serveSSE(w http.ResponseWriter, r *http.Request, c *streamClient, lastID uint64, resume bool) {
+	rc := http.NewResponseController(w)
+	// 清除 http.Server 的写超时，长连接由心跳维持
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// 先注册客户端再返回响应头，客户端收到响应后发布的消息不会丢失
+	backlog := es.attach(c, lastID, resume)
+	defer es.detach(c)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	write := func(entry streamEntry) error {
+		if !es.visible(c, entry) {
+			return nil
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", entry.id, entry.data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	for _, entry := range backlog {
+		if err := write(entry); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(es.options.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case entry := <-c.entries:
+			if err := write(entry); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := c.verify(); err != nil {
+				lib.Log.Debugf("[site] event stream client %d token expired, disconnect: %v", c.auth.UserId, err)
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveWebSocket 以 WebSocket 文本消息推送，每条消息为一个 JSON
+func (es *EventStream) serveWebSocket(w http.ResponseWriter, r *http.Request, c *streamClient, lastID uint64, resume bool) {
+	backlog := es.attach(c, lastID, resume)
+	defer es.detach(c)
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		lib.Log.Debugf("[site] %v", err)
+		return
+	}
+	go func() {
+		conn.readLoop()
+		es.detach(c)
+	}()
+
+	write := func(entry streamEntry) error {
+		if !es.visible(c, entry) {
+			return nil
+		}
+		return conn.WriteText(entry.data)
+	}
+	for _, entry := range backlog {
+		if err := write(entry); err != nil {
+			conn.conn.Close()
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(es.options.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case entry := <-c.entries:
+			if err := write(entry); err != nil {
+				conn.conn.Close()
+				return
+			}
+		case <-heartbeat.C:
+			if err := c.verify(); err != nil {
+				lib.Log.Debugf("[site] event stream client %d token expired, disconnect: %v", c.auth.UserId, err)
+				conn.Close(1008, "token expired")
+				return
+			}
+			if err := conn.Ping(); err != nil {
+				conn.conn.Close()
+				return
+			}
+		case <-c.done:
+			conn.Close(1001, "going away")
+			return
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package site
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/modules"
+	"github.com/gloopai/gloop/modules/auth"
+)
+
+type streamOrder struct {
+	Id     int   `json:"id"`
+	UserId int64 `json:"user_id"`
+}
+
+type received struct {
+	Id    uint64      `json:"id"`
+	Event string      `json:"event"`
+	Data  streamOrder `json:"data"`
+}
+
+// newStreamSite 创建只推送用户自己订单的站点
+func newStreamSite(t *testing.T) (*Site, *events.EventBus, *httptest.Server) {
+	bus := events.NewEventBus()
+	s := NewSite(SiteOptions{})
+	s.UseEventBus(bus)
+	s.UseAuth(&auth.Auth{
+		Config:     auth.AuthOptions{JWTOptions: auth.JWTOptions{Authorization: "Authorization"}},
+		JWTManager: auth.NewJWTManager(auth.JWTOptions{}),
+	})
+	stream, err := s.AddEventStream("/events", EventStreamOptions{
+		Topics: []string{"order.>"},
+		Filter: func(auth modules.RequestAuth, msg *events.EventMessage) bool {
+			order, ok := msg.Data.(streamOrder)
+			return ok && order.UserId == auth.UserId
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.mux)
+	t.Cleanup(func() {
+		stream.Close()
+		server.Close()
+	})
+	return s, bus, server
+}
+
+func token(t *testing.T, s *Site, userId int64) string {
+	token, err := s.Auth.JWTManager.GenerateToken(modules.RequestAuth{UserId: userId, Username: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// openSSE 连接事件流，lastID 不为空时携带 Last-Event-ID
+func openSSE(t *testing.T, ctx context.Context, url, token, lastID string) *bufio.Reader {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events", nil)
+	req.Header.Set("Authorization", token)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %s %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+// readSSE 读取下一个 SSE 事件，跳过心跳注释
+func readSSE(t *testing.T, r *bufio.Reader) (id string, event received) {
+	var data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatal(err)
+			}
+			return id, event
+		}
+	}
+}
+
+func TestEventStream_SSEFilterAndResume(t *testing.T) {
+	s, bus, server := newStreamSite(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first, stop := context.WithCancel(ctx)
+	r := openSSE(t, first, server.URL, token(t, s, 1), "")
+	bus.Publish("order.created", streamOrder{Id: 10, UserId: 2})
+	bus.Publish("user.created", streamOrder{Id: 11, UserId: 1})
+	bus.Publish("order.created", streamOrder{Id: 12, UserId: 1})
+
+	id, event := readSSE(t, r)
+	if id != "2" || event.Id != 2 || event.Event != "order.created" || event.Data.Id != 12 {
+		t.Fatalf("expected only the user's own order, got id=%s %+v", id, event)
+	}
+	stop()
+
+	// 断线期间发布的消息在重连后从缓冲区补齐
+	bus.Publish("order.paid", streamOrder{Id: 12, UserId: 1})
+	bus.Drain(ctx)
+	r = openSSE(t, ctx, server.URL, token(t, s, 1), "1")
+	for _, want := range []received{{Id: 2, Event: "order.created"}, {Id: 3, Event: "order.paid"}} {
+		if _, event := readSSE(t, r); event.Id != want.Id || event.Event != want.Event {
+			t.Errorf("expected %+v after resume, got %+v", want, event)
+		}
+	}
+}
+
+func TestEventStream_WebSocket(t *testing.T) {
+	s, bus, server := newStreamSite(t)
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET /events?token="+token(t, s, 1)+" HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected handshake %s %v", resp.Status, resp.Header)
+	}
+
+	bus.Publish("order.created", streamOrder{Id: 20, UserId: 2})
+	bus.Publish("order.created", streamOrder{Id: 21, UserId: 1})
+	op, payload := readFrame(t, br)
+	var event received
+	if err := json.Unmarshal(payload, &event); err != nil || op != wsOpText {
+		t.Fatalf("unexpected frame %x %s: %v", op, payload, err)
+	}
+	if event.Event != "order.created" || event.Data.Id != 21 {
+		t.Errorf("expected only the user's own order, got %+v", event)
+	}
+
+	// 客户端关闭时服务端回复关闭帧并移除客户端
+	conn.Write([]byte{0x80 | wsOpClose, 0x80 | 2, 0, 0, 0, 0, 0x03, 0xE8})
+	if op, _ := readFrame(t, br); op != wsOpClose {
+		t.Errorf("expected close frame, got %x", op)
+	}
+}
+
+// readFrame 读取服务端发送的不掩码帧
+func readFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestEventStream_RejectsUnauthorized(t *testing.T) {
+	_, _, server := newStreamSite(t)
+	for _, url := range []string{server.URL + "/events", server.URL + "/events?token=invalid"} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", url, resp.StatusCode)
+		}
+	}
+}
+
+// handshake 发送 WebSocket 握手请求，返回响应状态码
+func handshake(t *testing.T, url, origin string) int {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for k, v := range map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ=="} {
+		req.Header.Set(k, v)
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestEventStream_WebSocketOrigin(t *testing.T) {
+	s, _, server := newStreamSite(t)
+	url := server.URL + "/events?token=" + token(t, s, 1)
+	if status := handshake(t, url, "http://evil.example"); status != http.StatusForbidden {
+		t.Errorf("expected cross-origin handshake to be rejected, got %d", status)
+	}
+	if status := handshake(t, url, server.URL); status != http.StatusSwitchingProtocols {
+		t.Errorf("expected same-origin handshake to succeed, got %d", status)
+	}
+}
+
+func TestEventStream_DisconnectsExpiredToken(t *testing.T) {
+	bus := events.NewEventBus()
+	s := NewSite(SiteOptions{})
+	s.UseEventBus(bus)
+	s.UseAuth(&auth.Auth{
+		Config:     auth.AuthOptions{JWTOptions: auth.JWTOptions{Authorization: "Authorization"}},
+		JWTManager: auth.NewJWTManager(auth.JWTOptions{}),
+	})
+	s.Auth.JWTManager.SetTokenDuration(time.Second)
+	stream, err := s.AddEventStream("/events", EventStreamOptions{Topics: []string{"order.>"}, Heartbeat: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.mux)
+	defer server.Close()
+	defer stream.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r := openSSE(t, ctx, server.URL, token(t, s, 1), "")
+	if _, err := io.Copy(io.Discard, r); ctx.Err() != nil {
+		t.Fatalf("connection should be closed after the token expires: %v", err)
+	}
+}
+
+func TestSite_StopClosesStreams(t *testing.T) {
+	s, bus, _ := newStreamSite(t)
+	stream := s.streams[0]
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stream.sub != nil || bus.Stats().Patterns != 0 {
+		t.Error("stop should unsubscribe the event stream")
+	}
+
+	// 重新启动时恢复推送
+	s.Config.Port = 0
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(context.Background())
+	if stream.sub == nil || bus.Stats().Patterns != 1 {
+		t.Error("start should resubscribe the event stream")
+	}
+}
@@ -0,0 +1,213 @@
+package site
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 服务端 WebSocket（RFC 6455）的最小实现，只支持服务端发送文本消息，
+// 客户端发来的数据消息被忽略，Ping 自动回复 Pong
+
+const (
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+
+	wsMaxFrame = 64 << 10 // 客户端单个帧的最大长度
+)
+
+var errWebSocketClosed = errors.New("websocket closed")
+
+// isWebSocket 判断请求是否为 WebSocket 握手
+func isWebSocket(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowedOrigin 检查 WebSocket 握手的 Origin，防止其他站点的页面借用户的令牌建立连接。
+// 没有 Origin 请求头的非浏览器客户端允许；allowed 为空时只允许与请求 Host 相同的来源
+func allowedOrigin(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimRight(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// wsConn 已完成握手的 WebSocket 连接，写操作可并发调用
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	mu   sync.Mutex
+}
+
+// upgradeWebSocket 完成 WebSocket 握手并接管连接，失败时已向客户端返回错误
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "bad websocket handshake", http.StatusBadRequest)
+		return nil, fmt.Errorf("bad websocket handshake")
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("hijack websocket connection failed: %w", err)
+	}
+	// 清除 http.Server 设置的读写超时，长连接由心跳检测
+	conn.SetDeadline(time.Time{})
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	if _, err := rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept + "\r\n\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// WriteText 发送一条文本消息
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+// Ping 发送心跳
+func (c *wsConn) Ping() error {
+	return c.writeFrame(wsOpPing, nil)
+}
+
+// Close 发送关闭帧并关闭连接
+func (c *wsConn) Close(code uint16, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	payload = append(payload, reason...)
+	c.writeFrame(wsOpClose, payload)
+	return c.conn.Close()
+}
+
+// writeFrame 发送一个不分片、不掩码的帧
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | op
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readLoop 读取客户端的帧直到连接关闭：回复 Ping，收到关闭帧时回复关闭，其余消息忽略
+func (c *wsConn) readLoop() error {
+	for {
+		op, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return err
+			}
+		case wsOpClose:
+			code := uint16(1000)
+			if len(payload) >= 2 {
+				code = binary.BigEndian.Uint16(payload)
+			}
+			c.Close(code, "")
+			return errWebSocketClosed
+		}
+	}
+}
+
+// readFrame 读取一个客户端帧，客户端的帧必须带掩码
+func (c *wsConn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op := head[0] & 0x0F
+	if head[1]&0x80 == 0 {
+		c.Close(1002, "frames must be masked")
+		return 0, nil, fmt.Errorf("websocket frame is not masked")
+	}
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > wsMaxFrame {
+		c.Close(1009, "frame too large")
+		return 0, nil, fmt.Errorf("websocket frame too large: %d bytes", n)
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
@@ -0,0 +1,101 @@
+package modules
+
+import (
+	"context"
+	"time"
+)
+
+// ComponentState 组件生命周期状态
+type ComponentState string
+
+const (
+	StateInitializing ComponentState = "initializing" // 初始化或重启中
+	StateRunning      ComponentState = "running"      // 运行中
+	StateFailed       ComponentState = "failed"       // 初始化、启动或运行失败
+	StateStopped      ComponentState = "stopped"      // 已停止
+)
+
+// EventComponentStatePrefix 组件状态变化事件前缀，完整事件名为前缀加新状态，
+// 如 "container.component.failed"，事件数据为 ComponentStateChange
+const EventComponentStatePrefix = "container.component."
+
+// ComponentStateChange 组件状态变化事件数据
+type ComponentStateChange struct {
+	Component string         // 组件名称
+	From      ComponentState // 原状态
+	To        ComponentState // 新状态
+	Err       error          // 导致失败的错误
+	Restarts  int            // 已重启次数
+	Time      time.Time      // 发生时间
+}
+
+// ComponentStatus 组件当前状态快照
+type ComponentStatus struct {
+	Component string
+	State     ComponentState
+	Restarts  int
+	LastError error
+}
+
+// Runner 可选接口，组件的主循环，由容器在 Start 成功后放入独立 goroutine 监管运行。
+// ctx 结束时应尽快返回；返回错误或 panic 视为运行失败，按重启策略依次调用 Stop、Init、Start 重启。
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// RestartMode 重启模式
+type RestartMode int
+
+const (
+	RestartNever     RestartMode = iota // 从不重启
+	RestartOnFailure                    // 失败时重启
+	RestartAlways                       // 退出后总是重启
+)
+
+// RestartPolicy 组件重启策略，失败重启间隔按 InitialBackoff 指数增长，不超过 MaxBackoff
+type RestartPolicy struct {
+	Mode           RestartMode
+	MaxRestarts    int           // 最大重启次数，0 表示不限
+	InitialBackoff time.Duration // 首次重启等待时间，默认 1 秒
+	MaxBackoff     time.Duration // 最长重启等待时间，默认 1 分钟
+}
+
+// Supervised 可选接口，组件通过它声明默认的重启策略
+type Supervised interface {
+	RestartPolicy() RestartPolicy
+}
+
+// ShouldRestart 判断组件以 err 退出且已重启 restarts 次后是否需要重启
+func (p RestartPolicy) ShouldRestart(err error, restarts int) bool {
+	if p.MaxRestarts > 0 && restarts >= p.MaxRestarts {
+		return false
+	}
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Backoff 返回第 restarts+1 次重启前的等待时间
+func (p RestartPolicy) Backoff(restarts int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = time.Second
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = time.Minute
+	}
+	backoff := initial
+	for i := 0; i < restarts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
@@ -0,0 +1,141 @@
+package gloop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules"
+)
+
+// EventConfigReloaded 配置热加载成功后发布的事件，事件数据为 ConfigReload
+const EventConfigReloaded = "container.config.reloaded"
+
+// ConfigReload 配置热加载事件数据
+type ConfigReload struct {
+	Sections []string  // 发生变化的组件配置段
+	Time     time.Time // 加载时间
+}
+
+// reloadChange 一个配置段发生变化的组件
+type reloadChange struct {
+	comp    modules.ComponentV2
+	target  any // 实现 Configurable 的组件，v1 组件为原始组件
+	section string
+	old     any
+	new     any
+}
+
+// Reload 重新分层加载配置，解析发生变化的组件配置段并通知实现了 modules.Reconfigurable 的组件。
+// 新配置解析或校验失败时拒绝整个加载，保持当前配置不变；
+// 组件拒绝的配置段不会被记录为已加载，下次热加载时重新尝试。
+// 日志级别与调试开关立即生效，其余容器配置（如 AdminAddr）需重启后生效。
+func (c *Container) Reload() error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	config, tree, err := loadContainerOptions(c.layers)
+	if err != nil {
+		return fmt.Errorf("reload rejected: %w", err)
+	}
+
+	var changes []reloadChange
+	for _, comp := range c.components {
+		target := modules.Unwrap(comp)
+		configurable, ok := target.(modules.Configurable)
+		if !ok {
+			continue
+		}
+		section := configurable.ConfigSection()
+		if reflect.DeepEqual(c.configTree.Section(section), tree.Section(section)) {
+			continue
+		}
+		current := configurable.ConfigOptions()
+		old, next := cloneOptions(current), cloneOptions(current)
+		if err := decodeSection(tree, section, next); err != nil {
+			return fmt.Errorf("reload rejected, configure component '%s' failed: %w", comp.Name(), err)
+		}
+		if reflect.DeepEqual(old, next) {
+			continue
+		}
+		changes = append(changes, reloadChange{comp: comp, target: target, section: section, old: old, new: next})
+	}
+
+	if c.Config != nil && config.AdminAddr != c.Config.AdminAddr {
+		lib.Log.Warn("[Container] AdminAddr change takes effect after restart")
+	}
+	lib.Log.SetLogLevel(config.LogLevel)
+	lib.Log.SetDebugEnabled(config.Debug)
+	if c.Config != nil {
+		c.applyServicePolicies(c.Config.ServicePolicies, config.ServicePolicies)
+	}
+
+	var errs []error
+	sections := make([]string, 0, len(changes))
+	for _, change := range changes {
+		reconfigurable, ok := change.target.(modules.Reconfigurable)
+		if !ok {
+			sections = append(sections, change.section)
+			lib.Log.Warnf("[Container] config [%s] changed, component '%s' requires restart to apply it", change.section, change.comp.Name())
+			continue
+		}
+		if err := reconfigure(reconfigurable, change.old, change.new); err != nil {
+			lib.Log.Errorf("[Container] component '%s' rejected config [%s]: %v", change.comp.Name(), change.section, err)
+			errs = append(errs, fmt.Errorf("reconfigure component '%s' failed: %w", change.comp.Name(), err))
+			// 保留原配置段，下次热加载时仍视为变化
+			tree = tree.WithSection(change.section, c.configTree.Section(change.section))
+			continue
+		}
+		sections = append(sections, change.section)
+	}
+	c.Config = config
+	c.configTree = tree
+
+	lib.Log.Infof("[Container] configuration reloaded, changed sections: %v", sections)
+	if c.Events != nil {
+		c.Events.Publish(EventConfigReloaded, ConfigReload{Sections: sections, Time: time.Now()})
+	}
+	return errors.Join(errs...)
+}
+
+// doWatchConfig 按 ReloadInterval 轮询配置文件，发生变化时热加载，ctx 结束时停止
+func (c *Container) doWatchConfig(ctx context.Context) {
+	config := c.config()
+	if config == nil || config.ReloadInterval <= 0 {
+		return
+	}
+	files := lib.Conf.LayeredFiles(c.layers)
+	if len(files) == 0 {
+		return
+	}
+	go lib.Conf.WatchFiles(ctx, files, config.ReloadInterval, func() {
+		lib.Log.Infof("[Container] configuration file changed, reloading")
+		if err := c.Reload(); err != nil {
+			lib.Log.Errorf("[Container] %v", err)
+		}
+	})
+}
+
+// reconfigure 调用组件的 Reconfigure，并将 panic 转换为错误
+func reconfigure(r modules.Reconfigurable, old, new any) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("reconfigure panic: %v", p)
+		}
+	}()
+	return r.Reconfigure(old, new)
+}
+
+// cloneOptions 浅拷贝结构体指针类型的配置，非指针原样返回
+func cloneOptions(options any) any {
+	v := reflect.ValueOf(options)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return options
+	}
+	clone := reflect.New(v.Elem().Type())
+	clone.Elem().Set(v.Elem())
+	return clone.Interface()
+}
@@ -0,0 +1,128 @@
+package gloop
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules/site"
+)
+
+func TestContainer_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "container.toml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`
+[site.public]
+port = 8081
+`)
+	layers := lib.LayerOptions{Files: []string{path}, Args: []string{}, Environ: []string{}}
+	config, tree, err := loadContainerOptions(layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := site.NewSite(site.SiteOptions{Name: "public"})
+	c := &Container{Config: config, configTree: tree, layers: layers}
+	c.Add(s)
+	if err := c.doConfigureComponents(); err != nil {
+		t.Fatal(err)
+	}
+
+	write(`
+LogLevel = 5
+
+[site.public]
+port = 8081
+cross_origin = true
+static_file_cache_ttl = "3m"
+`)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Config.CrossOrigin || s.Config.StaticFileCacheTTL != 3*time.Minute {
+		t.Errorf("site not reconfigured: %+v", s.Config)
+	}
+	if c.Config.LogLevel != lib.LogLevelDebug {
+		t.Errorf("container config not reloaded: %+v", c.Config)
+	}
+	lib.Log.SetLogLevel(lib.LogLevelInfo)
+
+	write(`
+[site.public]
+port = 70000
+cross_origin = false
+`)
+	err = c.Reload()
+	if err == nil || !strings.Contains(err.Error(), "reload rejected") {
+		t.Fatalf("expected rejected reload, got %v", err)
+	}
+	if !s.Config.CrossOrigin || c.Config.LogLevel != lib.LogLevelDebug {
+		t.Errorf("running config should be kept after rejected reload: %+v %+v", s.Config, c.Config)
+	}
+}
+
+type pickyOptions struct {
+	Level int `toml:"level"`
+}
+
+// pickyComponent 第一次热加载时拒绝新配置
+type pickyComponent struct {
+	config  pickyOptions
+	rejects int
+}
+
+func (p *pickyComponent) Name() string                    { return "picky" }
+func (p *pickyComponent) Init(ctx context.Context) error  { return nil }
+func (p *pickyComponent) Start(ctx context.Context) error { return nil }
+func (p *pickyComponent) Stop(ctx context.Context) error  { return nil }
+func (p *pickyComponent) ConfigSection() string           { return "picky" }
+func (p *pickyComponent) ConfigOptions() any              { return &p.config }
+
+func (p *pickyComponent) Reconfigure(old, new any) error {
+	if p.rejects == 0 {
+		p.rejects++
+		return errors.New("not now")
+	}
+	p.config = *new.(*pickyOptions)
+	return nil
+}
+
+func TestContainer_ReloadRetriesRejectedSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "container.toml")
+	if err := os.WriteFile(path, []byte("[picky]\nlevel = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	layers := lib.LayerOptions{Files: []string{path}, Args: []string{}, Environ: []string{}}
+	config, tree, err := loadContainerOptions(layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comp := &pickyComponent{}
+	c := &Container{Config: config, configTree: tree, layers: layers}
+	c.AddV2(comp)
+	if err := c.doConfigureComponents(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("[picky]\nlevel = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err == nil {
+		t.Fatal("expected the component to reject the first reload")
+	}
+	// 配置文件未变化，被拒绝的配置段仍会再次交给组件
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if comp.config.Level != 2 {
+		t.Errorf("rejected section should be retried on the next reload, got %+v", comp.config)
+	}
+}
@@ -0,0 +1,159 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 异步调用，稍后等待结果
+future := servicehub.CallAsync[*UserReq, *User](ctx, hub, "user.get", req)
+...
+user, err := future.Await(ctx)
+
+// 调用所有提供者并汇总结果，如收集各模块的健康信息
+results, err := servicehub.CallAll[*StatusReq, *Status](ctx, hub, "module.status", req)
+statuses := results.Values()
+if err := results.Err(); err != nil {
+	log.Println("some providers failed:", err)
+}
+
+*/
+
+// Future 异步调用的结果
+type Future[Resp any] struct {
+	done chan struct{}
+	resp Resp
+	err  error
+}
+
+// CallAsync 在新的 goroutine 中调用服务并立即返回，语义与 CallContext 相同，
+// 服务函数的 panic 转换为 *ErrServicePanic 错误
+func CallAsync[Req any, Resp any](ctx context.Context, h *ServiceHub, name string, req Req) *Future[Resp] {
+	f := &Future[Resp]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		defer func() {
+			if r := recover(); r != nil {
+				f.err = asPanicError(name, r)
+			}
+		}()
+		f.resp, f.err = CallContext[Req, Resp](ctx, h, name, req)
+	}()
+	return f
+}
+
+// Done 调用完成时关闭，可用于 select
+func (f *Future[Resp]) Done() <-chan struct{} { return f.done }
+
+// Await 等待调用完成，ctx 结束时返回 ctx 的错误，调用本身不会被取消
+func (f *Future[Resp]) Await(ctx context.Context) (Resp, error) {
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		var zero Resp
+		return zero, ctx.Err()
+	}
+}
+
+// Result 阻塞等待调用完成
+func (f *Future[Resp]) Result() (Resp, error) {
+	<-f.done
+	return f.resp, f.err
+}
+
+// Result 一个提供者的调用结果
+type Result[Resp any] struct {
+	Provider string
+	Version  string
+	Resp     Resp
+	Err      error
+}
+
+// Results 扇出调用的结果，与提供者的注册顺序相同
+type Results[Resp any] []Result[Resp]
+
+// Values 返回成功的响应
+func (rs Results[Resp]) Values() []Resp {
+	values := make([]Resp, 0, len(rs))
+	for _, r := range rs {
+		if r.Err == nil {
+			values = append(values, r.Resp)
+		}
+	}
+	return values
+}
+
+// Err 合并失败提供者的错误，全部成功时为 nil
+func (rs Results[Resp]) Err() error {
+	var errs []error
+	for _, r := range rs {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("provider '%s': %w", r.Provider, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CallAll 并发调用服务版本范围内最高版本的所有本地提供者（不含下线中的提供者），等待全部返回。
+// 只在找不到服务或类型不匹配时返回错误，单个提供者的失败记录在结果中；
+// 经过拦截器与超时控制，不应用弹性策略与负载均衡
+func CallAll[Req any, Resp any](ctx context.Context, h *ServiceHub, name string, req Req) (Results[Resp], error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	lib.Log.Debugf("\033[33m[ServiceHub] call all providers of service %s%s\033[0m", name, traceSuffix(ctx))
+	reqType, respType := reflect.TypeFor[Req](), reflect.TypeFor[Resp]()
+	entries, _, err := h.candidates(name, func(e *serviceEntry) bool {
+		return !e.stream && e.reqType == reqType && e.respType == respType
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(Results[Resp], len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		results[i].Provider, results[i].Version = entry.provider, entry.versionString()
+		wg.Add(1)
+		go func(r *Result[Resp], entry *serviceEntry) {
+			defer wg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					r.Err = asPanicError(name, p)
+				}
+			}()
+			resp, err := h.invokeEntry(ctx, name, entry, req)
+			r.Resp, _ = resp.(Resp)
+			r.Err = err
+		}(&results[i], entry)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// asPanicError 将 recover 得到的值转换为 *ErrServicePanic
+func asPanicError(name string, r any) error {
+	if err, ok := r.(*ErrServicePanic); ok {
+		return err
+	}
+	return &ErrServicePanic{Name: name, Value: r, Stack: debug.Stack()}
+}
+
+// CallAsyncFromService 通过单例异步调用一个服务
+func CallAsyncFromService[Req any, Resp any](ctx context.Context, name string, req Req) *Future[Resp] {
+	return CallAsync[Req, Resp](ctx, GetHubInstance(), name, req)
+}
+
+// CallAllFromService 通过单例调用服务的所有提供者
+func CallAllFromService[Req any, Resp any](ctx context.Context, name string, req Req) (Results[Resp], error) {
+	return CallAll[Req, Resp](ctx, GetHubInstance(), name, req)
+}
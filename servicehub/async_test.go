@@ -0,0 +1,127 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCallAsync(t *testing.T) {
+	h := NewServiceHub()
+	release := make(chan struct{})
+	Register(h, "double", func(req int) (int, error) {
+		<-release
+		return req * 2, nil
+	})
+	Register(h, "boom", func(req int) (int, error) { panic("boom") })
+
+	future := CallAsync[int, int](context.Background(), h, "double", 21)
+	select {
+	case <-future.Done():
+		t.Fatal("future completed before the service returned")
+	default:
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := future.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected await deadline, got %v", err)
+	}
+	close(release)
+	if resp, err := future.Result(); err != nil || resp != 42 {
+		t.Errorf("expected 42, got %d, %v", resp, err)
+	}
+
+	var panicErr *ErrServicePanic
+	if _, err := CallAsync[int, int](context.Background(), h, "boom", 1).Result(); !errors.As(err, &panicErr) {
+		t.Errorf("expected panic error, got %v", err)
+	}
+}
+
+func TestCallAll_AggregatesProviders(t *testing.T) {
+	h := NewServiceHub()
+	registerProviders(t, h, "a", "b")
+	Register(h, "whoami", func(req int) (string, error) { return "", errors.New("down") }, WithProvider("c"))
+
+	results, err := CallAll[int, string](context.Background(), h, "whoami", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if values := results.Values(); len(values) != 2 || values[0] != "a" || values[1] != "b" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if err := results.Err(); err == nil || results[2].Provider != "c" {
+		t.Errorf("expected failure of provider c, got %v", err)
+	}
+
+	if _, err := CallAll[string, string](context.Background(), h, "whoami", ""); err == nil {
+		t.Error("expected type mismatch")
+	}
+}
+
+func TestCallStream(t *testing.T) {
+	h := NewServiceHub()
+	stopped := make(chan error, 1)
+	err := RegisterStream(h, "count", func(ctx context.Context, n int, send func(int) error) error {
+		for i := 1; i <= n; i++ {
+			if err := send(i); err != nil {
+				stopped <- err
+				return err
+			}
+		}
+		if n == 0 {
+			return errors.New("empty")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := CallStream[int, int](context.Background(), h, "count", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for v, err := range stream.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != 3 || got[2] != 3 {
+		t.Errorf("unexpected stream values: %v", got)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF after stream end, got %v", err)
+	}
+
+	// 提前退出循环取消服务
+	stream, _ = CallStream[int, int](context.Background(), h, "count", 1000)
+	for v := range stream.All() {
+		if v == 2 {
+			break
+		}
+	}
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected send to fail with canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream provider was not canceled")
+	}
+
+	stream, _ = CallStream[int, int](context.Background(), h, "count", 0)
+	if _, err := stream.Recv(); err == nil || err.Error() != "empty" {
+		t.Errorf("expected provider error, got %v", err)
+	}
+
+	if _, err := Call[int, int](h, "count", 1); err == nil {
+		t.Error("stream service should not be callable with Call")
+	}
+}
@@ -0,0 +1,154 @@
+package servicehub
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+/**
+
+// 同一服务名称注册多个提供者，按策略选择
+servicehub.RegisterToService("user.get", getUserA, servicehub.WithProvider("a"), servicehub.WithStrategy(servicehub.LeastInFlight()))
+servicehub.RegisterToService("user.get", getUserB, servicehub.WithProvider("b"), servicehub.WithWeight(3))
+
+// 按用户固定到同一提供者
+ctx = servicehub.WithStickyKey(ctx, strconv.FormatInt(userId, 10))
+
+// 下线提供者：不再分配新调用，等待进行中的调用完成后注销
+servicehub.GetHubInstance().DrainProvider(ctx, "user.get", "a")
+
+*/
+
+// Provider 服务提供者的只读视图，供负载均衡策略选择
+type Provider struct {
+	ID       string // 提供者标识，WithProvider 指定
+	Version  string // 提供者版本，未指定版本时为空
+	Weight   int    // 权重，默认 1
+	InFlight int64  // 进行中的调用数
+}
+
+// Strategy 负载均衡策略，从候选提供者中选择一个并返回其下标，providers 不为空。
+// 同一策略实例只用于一个服务，可以保存状态
+type Strategy interface {
+	Select(ctx context.Context, providers []Provider) int
+}
+
+// StrategyFunc 函数形式的无状态策略
+type StrategyFunc func(ctx context.Context, providers []Provider) int
+
+func (f StrategyFunc) Select(ctx context.Context, providers []Provider) int { return f(ctx, providers) }
+
+// WithProvider 指定提供者标识，同一服务名称下不同标识的提供者可以同时存在
+func WithProvider(id string) RegisterOption {
+	return func(o *registerOptions) { o.provider = id }
+}
+
+// WithWeight 设置提供者权重，用于 Weighted 策略
+func WithWeight(weight int) RegisterOption {
+	return func(o *registerOptions) { o.weight = weight }
+}
+
+// WithStrategy 设置服务的负载均衡策略，默认 RoundRobin
+func WithStrategy(strategy Strategy) RegisterOption {
+	return func(o *registerOptions) { o.strategy = strategy }
+}
+
+// SetStrategy 设置已注册服务的负载均衡策略
+func (h *ServiceHub) SetStrategy(name string, strategy Strategy) error {
+	base, _ := splitServiceName(name)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	svc, exists := h.services[base]
+	if !exists {
+		return &ErrServiceNotFound{name}
+	}
+	svc.strategy = strategy
+	return nil
+}
+
+// RoundRobin 轮询
+func RoundRobin() Strategy {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (r *roundRobin) Select(ctx context.Context, providers []Provider) int {
+	return int((r.next.Add(1) - 1) % uint64(len(providers)))
+}
+
+// Random 随机
+func Random() Strategy {
+	return StrategyFunc(func(ctx context.Context, providers []Provider) int {
+		return rand.IntN(len(providers))
+	})
+}
+
+// LeastInFlight 选择进行中调用最少的提供者，相同时轮询
+func LeastInFlight() Strategy {
+	rr := &roundRobin{}
+	return StrategyFunc(func(ctx context.Context, providers []Provider) int {
+		offset := rr.Select(ctx, providers)
+		best := offset
+		for i := range providers {
+			index := (offset + i) % len(providers)
+			if providers[index].InFlight < providers[best].InFlight {
+				best = index
+			}
+		}
+		return best
+	})
+}
+
+// Weighted 按权重随机选择，权重不大于 0 的提供者按 1 计算
+func Weighted() Strategy {
+	return StrategyFunc(func(ctx context.Context, providers []Provider) int {
+		total := 0
+		for _, p := range providers {
+			total += max(p.Weight, 1)
+		}
+		n := rand.IntN(total)
+		for i, p := range providers {
+			if n -= max(p.Weight, 1); n < 0 {
+				return i
+			}
+		}
+		return len(providers) - 1
+	})
+}
+
+type stickyKey struct{}
+
+// WithStickyKey 返回携带粘性键的 context，Sticky 策略将相同的键分配给同一提供者
+func WithStickyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, stickyKey{}, key)
+}
+
+// Sticky 按 ctx 中的粘性键选择提供者，使用最高随机权重哈希，
+// 提供者增减时只有少数键改变分配；没有粘性键时使用 fallback，为 nil 时轮询
+func Sticky(fallback Strategy) Strategy {
+	if fallback == nil {
+		fallback = RoundRobin()
+	}
+	return StrategyFunc(func(ctx context.Context, providers []Provider) int {
+		key, ok := ctx.Value(stickyKey{}).(string)
+		if !ok {
+			return fallback.Select(ctx, providers)
+		}
+		best, bestScore := 0, uint64(0)
+		for i, p := range providers {
+			hash := fnv.New64a()
+			hash.Write([]byte(key))
+			hash.Write([]byte{0})
+			hash.Write([]byte(p.ID))
+			if score := hash.Sum64(); i == 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		return best
+	})
+}
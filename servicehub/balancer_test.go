@@ -0,0 +1,200 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func registerProviders(t *testing.T, h *ServiceHub, ids ...string) {
+	for _, id := range ids {
+		id := id
+		if err := Register(h, "whoami", func(req int) (string, error) { return id, nil }, WithProvider(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProviders_RoundRobin(t *testing.T) {
+	h := NewServiceHub()
+	registerProviders(t, h, "a", "b", "c")
+	if err := Register(h, "whoami", func(req int) (string, error) { return "dup", nil }, WithProvider("a")); err == nil {
+		t.Error("duplicate provider should be rejected")
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 9; i++ {
+		id, err := Call[int, string](h, "whoami", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[id]++
+	}
+	if counts["a"] != 3 || counts["b"] != 3 || counts["c"] != 3 {
+		t.Errorf("unexpected distribution: %v", counts)
+	}
+}
+
+func TestProviders_Sticky(t *testing.T) {
+	h := NewServiceHub()
+	registerProviders(t, h, "a", "b", "c")
+	h.SetStrategy("whoami", Sticky(nil))
+
+	ctx := WithStickyKey(context.Background(), "user-42")
+	first, _ := CallContext[int, string](ctx, h, "whoami", 0)
+	for i := 0; i < 5; i++ {
+		if id, _ := CallContext[int, string](ctx, h, "whoami", 0); id != first {
+			t.Fatalf("sticky key moved from %s to %s", first, id)
+		}
+	}
+}
+
+func TestProviders_LeastInFlightAndWeighted(t *testing.T) {
+	providers := []Provider{{ID: "a", InFlight: 3}, {ID: "b", InFlight: 1}, {ID: "c", InFlight: 2}}
+	if i := LeastInFlight().Select(context.Background(), providers); i != 1 {
+		t.Errorf("expected least in-flight provider b, got %d", i)
+	}
+	weighted := []Provider{{ID: "a", Weight: 0}, {ID: "b", Weight: 1000000}}
+	hits := 0
+	for i := 0; i < 100; i++ {
+		if Weighted().Select(context.Background(), weighted) == 1 {
+			hits++
+		}
+	}
+	if hits < 95 {
+		t.Errorf("weighted selection ignored weights: %d/100", hits)
+	}
+}
+
+func TestProviders_Drain(t *testing.T) {
+	h := NewServiceHub()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	Register(h, "job", func(req int) (string, error) {
+		close(started)
+		<-release
+		return "old", nil
+	}, WithProvider("old"))
+
+	done := make(chan string)
+	go func() {
+		resp, _ := Call[int, string](h, "job", 0)
+		done <- resp
+	}()
+	<-started
+	Register(h, "job", func(req int) (string, error) { return "new", nil }, WithProvider("new"))
+
+	drained := make(chan error)
+	go func() { drained <- h.DrainProvider(context.Background(), "job", "old") }()
+	time.Sleep(20 * time.Millisecond)
+	// 下线中的提供者不再分配新的调用
+	for i := 0; i < 3; i++ {
+		if resp, _ := Call[int, string](h, "job", 0); resp != "new" {
+			t.Fatalf("draining provider received a call: %s", resp)
+		}
+	}
+	select {
+	case <-drained:
+		t.Fatal("drain returned before in-flight call completed")
+	default:
+	}
+	close(release)
+	if resp := <-done; resp != "old" {
+		t.Errorf("in-flight call interrupted: %s", resp)
+	}
+	if err := <-drained; err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := h.Describe("job"); len(info.Providers) != 1 || info.Providers[0].ID != "new" {
+		t.Errorf("drained provider not removed: %+v", info.Providers)
+	}
+}
+
+type userV1 struct{ Name string }
+type userV2 struct{ FullName string }
+
+func TestVersions(t *testing.T) {
+	h := NewServiceHub()
+	Register(h, "user.get@v1.4.0", func(req int) (*userV1, error) { return &userV1{Name: "1.4"}, nil })
+	Register(h, "user.get@1.5.2", func(req int) (*userV1, error) { return &userV1{Name: "1.5.2"}, nil })
+	Register(h, "user.get@v2", func(req int) (*userV2, error) { return &userV2{FullName: "2.0"}, nil })
+	if err := Register(h, "user.get@vx", func(req int) (int, error) { return 0, nil }); err == nil {
+		t.Error("invalid version should be rejected")
+	}
+
+	cases := map[string]string{
+		"user.get":          "1.5.2",
+		"user.get@^1":       "1.5.2",
+		"user.get@~1.4":     "1.4",
+		"user.get@1.4.0":    "1.4",
+		"user.get@>=1 <1.5": "1.4",
+		"user.get@1.x":      "1.5.2",
+	}
+	for name, expected := range cases {
+		resp, err := Call[int, *userV1](h, name, 0)
+		if err != nil || resp.Name != expected {
+			t.Errorf("%s: expected %s, got %+v %v", name, expected, resp, err)
+		}
+	}
+	if resp, err := Call[int, *userV2](h, "user.get", 0); err != nil || resp.FullName != "2.0" {
+		t.Errorf("expected v2 by type, got %+v %v", resp, err)
+	}
+
+	var notFound *ErrServiceNotFound
+	if _, err := Call[int, *userV1](h, "user.get@^3", 0); !errors.As(err, &notFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	var typeErr *ErrServiceType
+	if _, err := Call[int, *userV1](h, "user.get@2", 0); !errors.As(err, &typeErr) {
+		t.Errorf("expected type mismatch, got %v", err)
+	}
+	if names := h.ListServices(); len(names) != 3 || names[0] != "user.get@1.4.0" {
+		t.Errorf("unexpected services: %v", names)
+	}
+
+	h.Unregister("user.get@1.5.2")
+	if resp, _ := Call[int, *userV1](h, "user.get", 0); resp == nil || resp.Name != "1.4" {
+		t.Errorf("unregistered version still resolved: %+v", resp)
+	}
+}
+
+func TestVersionRange(t *testing.T) {
+	cases := []struct {
+		rng      string
+		version  string
+		expected bool
+	}{
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<2", "2.0.0-beta", false},
+		{"1.2.3 || ^3", "3.1.0", true},
+		{"*", "0.0.1", true},
+		{"2.0.0-beta.2", "2.0.0-beta.2", true},
+	}
+	for _, c := range cases {
+		rng, err := ParseVersionRange(c.rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := ParseVersion(c.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rng.Contains(v) != c.expected {
+			t.Errorf("%s contains %s: expected %t", c.rng, c.version, c.expected)
+		}
+	}
+	a, _ := ParseVersion("1.0.0-alpha.2")
+	b, _ := ParseVersion("1.0.0-alpha.10")
+	if a.Compare(b) >= 0 {
+		t.Error("numeric prerelease identifiers should compare numerically")
+	}
+}
@@ -0,0 +1,234 @@
+package servicehub
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+
+// 注册时记录组件，目录中包含请求与响应的 JSON Schema 与调用统计
+servicehub.RegisterToService("user.get", getUser, servicehub.WithComponent("user"))
+
+for _, info := range servicehub.GetHubInstance().Services() {
+	fmt.Println(info.Name, info.Component, info.Calls, info.LastError)
+}
+
+// 挂载到管理端口：GET /services 返回目录，GET /services?name=user.get 返回单个服务
+mux.Handle("/services", servicehub.GetHubInstance().CatalogHandler())
+
+*/
+
+// ServiceInfo 服务目录中的一项，节点之间据此发现服务并校验类型
+type ServiceInfo struct {
+	Name           string         `json:"name"`
+	Version        string         `json:"version,omitempty"`
+	Description    string         `json:"description,omitempty"`
+	Component      string         `json:"component,omitempty"`
+	RequestType    string         `json:"request_type"`
+	ResponseType   string         `json:"response_type"`
+	RequestSchema  map[string]any `json:"request_schema,omitempty"`
+	ResponseSchema map[string]any `json:"response_schema,omitempty"`
+	Stream         bool           `json:"stream,omitempty"` // 流式服务，响应为多条 ResponseType
+
+	Calls       int64      `json:"calls"`
+	Failures    int64      `json:"failures"`
+	InFlight    int64      `json:"in_flight"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+
+	Breaker BreakerState `json:"breaker,omitempty"` // 熔断状态，未配置熔断时为空
+
+	Providers []ProviderInfo `json:"providers,omitempty"`
+}
+
+// ProviderInfo 服务目录中的提供者
+type ProviderInfo struct {
+	ID       string `json:"id"`
+	Weight   int    `json:"weight,omitempty"`
+	Draining bool   `json:"draining,omitempty"`
+	Calls    int64  `json:"calls"`
+	Failures int64  `json:"failures"`
+	InFlight int64  `json:"in_flight"`
+}
+
+// fullName 返回带版本的服务名称
+func (info ServiceInfo) fullName() string {
+	if info.Version == "" {
+		return info.Name
+	}
+	return info.Name + "@" + info.Version
+}
+
+// WithComponent 记录注册服务的组件名称
+func WithComponent(component string) RegisterOption {
+	return func(o *registerOptions) { o.component = component }
+}
+
+// RegisteringFor 在返回的 end 调用前注册、且未使用 WithComponent 的服务记录为 component 注册。
+// 容器在调用组件的 RegisterService 期间使用，组件无需自行设置 WithComponent
+func (h *ServiceHub) RegisteringFor(component string) (end func()) {
+	h.mu.Lock()
+	h.registering = component
+	h.mu.Unlock()
+	return func() {
+		h.mu.Lock()
+		h.registering = ""
+		h.mu.Unlock()
+	}
+}
+
+// serviceStats 服务调用统计
+type serviceStats struct {
+	calls    atomic.Int64
+	failures atomic.Int64
+	inFlight atomic.Int64
+
+	mu        sync.Mutex
+	lastErr   string
+	lastErrAt time.Time
+
+	schemaOnce     sync.Once
+	requestSchema  map[string]any
+	responseSchema map[string]any
+}
+
+func (s *serviceStats) begin() {
+	s.calls.Add(1)
+	s.inFlight.Add(1)
+}
+
+func (s *serviceStats) end(err error) {
+	s.inFlight.Add(-1)
+	if err == nil {
+		return
+	}
+	s.failures.Add(1)
+	s.mu.Lock()
+	s.lastErr, s.lastErrAt = err.Error(), time.Now()
+	s.mu.Unlock()
+}
+
+// serviceInfo 返回同一名称与版本的提供者汇总的目录项，JSON Schema 在首次查询时生成
+func serviceInfo(name string, entries []*serviceEntry) ServiceInfo {
+	first := entries[0]
+	info := ServiceInfo{
+		Name:         name,
+		Version:      first.versionString(),
+		Description:  first.description,
+		Component:    first.component,
+		RequestType:  first.reqType.String(),
+		ResponseType: first.respType.String(),
+		Stream:       first.stream,
+	}
+	for _, e := range entries {
+		if info.Description == "" {
+			info.Description = e.description
+		}
+		if info.Component == "" {
+			info.Component = e.component
+		}
+		if e.stats == nil {
+			continue
+		}
+		e.stats.schemaOnce.Do(func() {
+			e.stats.requestSchema = JSONSchema(e.reqType)
+			e.stats.responseSchema = JSONSchema(e.respType)
+		})
+		if info.RequestSchema == nil {
+			info.RequestSchema, info.ResponseSchema = e.stats.requestSchema, e.stats.responseSchema
+		}
+		provider := ProviderInfo{
+			ID:       e.provider,
+			Weight:   e.weight,
+			Draining: e.draining.Load(),
+			Calls:    e.stats.calls.Load(),
+			Failures: e.stats.failures.Load(),
+			InFlight: e.stats.inFlight.Load(),
+		}
+		info.Calls += provider.Calls
+		info.Failures += provider.Failures
+		info.InFlight += provider.InFlight
+		info.Providers = append(info.Providers, provider)
+
+		e.stats.mu.Lock()
+		if e.stats.lastErr != "" && (info.LastErrorAt == nil || e.stats.lastErrAt.After(*info.LastErrorAt)) {
+			lastErrAt := e.stats.lastErrAt
+			info.LastError, info.LastErrorAt = e.stats.lastErr, &lastErrAt
+		}
+		e.stats.mu.Unlock()
+	}
+	return info
+}
+
+// Services 返回本地服务目录，每个名称与版本一项，按名称与版本排序
+func (h *ServiceHub) Services() []ServiceInfo {
+	h.mu.RLock()
+	groups := make(map[string][]*serviceEntry)
+	names := make(map[string]string)
+	for base, svc := range h.services {
+		for _, e := range svc.providers {
+			key := base + "@" + e.versionString()
+			groups[key] = append(groups[key], e)
+			names[key] = base
+		}
+	}
+	h.mu.RUnlock()
+
+	infos := make([]ServiceInfo, 0, len(groups))
+	for key, entries := range groups {
+		info := serviceInfo(names[key], entries)
+		info.Breaker = h.breakerState(info.Name)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name != infos[j].Name {
+			return infos[i].Name < infos[j].Name
+		}
+		return compareEntryVersion(infos[i].entryVersion(), infos[j].entryVersion()) < 0
+	})
+	return infos
+}
+
+// entryVersion 用于排序的版本
+func (info ServiceInfo) entryVersion() *serviceEntry {
+	v, err := ParseVersion(info.Version)
+	return &serviceEntry{version: v, versioned: err == nil}
+}
+
+// Describe 返回单个本地服务的目录项，名称可以带版本范围，返回匹配的最高版本
+func (h *ServiceHub) Describe(name string) (ServiceInfo, bool) {
+	matched, _, err := h.candidates(name, nil)
+	if err != nil {
+		return ServiceInfo{}, false
+	}
+	base, _ := splitServiceName(name)
+	info := serviceInfo(base, h.providers(base, func(e *serviceEntry) bool {
+		return compareEntryVersion(e, matched[0]) == 0
+	}))
+	info.Breaker = h.breakerState(base)
+	return info, true
+}
+
+// CatalogHandler 返回输出服务目录的 HTTP 处理器，带 name 参数时只输出该服务，不存在时返回 404
+func (h *ServiceHub) CatalogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if name := r.URL.Query().Get("name"); name != "" {
+			info, ok := h.Describe(name)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"error": (&ErrServiceNotFound{name}).Error()})
+				return
+			}
+			json.NewEncoder(w).Encode(info)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"services": h.Services()})
+	}
+}
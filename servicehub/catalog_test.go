@@ -0,0 +1,93 @@
+package servicehub
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type treeNode struct {
+	Name     string      `json:"name" validate:"required"`
+	Children []*treeNode `json:"children,omitempty"`
+	Tags     map[string]string
+	Created  time.Time `json:"created"`
+	Secret   string    `json:"-"`
+	private  int
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema(reflect.TypeFor[*treeNode]())
+	data, _ := json.Marshal(schema)
+
+	var decoded struct {
+		Ref  string `json:"$ref"`
+		Defs map[string]struct {
+			Type       string                     `json:"type"`
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	def, ok := decoded.Defs["servicehub.treeNode"]
+	if decoded.Ref != "#/$defs/servicehub.treeNode" || !ok {
+		t.Fatalf("unexpected schema: %s", data)
+	}
+	if def.Type != "object" || len(def.Required) != 1 || def.Required[0] != "name" {
+		t.Errorf("unexpected definition: %+v", def)
+	}
+	for _, key := range []string{"name", "children", "Tags", "created"} {
+		if _, ok := def.Properties[key]; !ok {
+			t.Errorf("missing property %s in %s", key, data)
+		}
+	}
+	if len(def.Properties) != 4 {
+		t.Errorf("unexpected properties in %s", data)
+	}
+	if string(def.Properties["children"]) != `{"items":{"$ref":"#/$defs/servicehub.treeNode"},"type":"array"}` {
+		t.Errorf("recursive reference not used: %s", def.Properties["children"])
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	h := NewServiceHub()
+	Register(h, "tree.get", func(req int) (*treeNode, error) {
+		if req < 0 {
+			return nil, errors.New("negative id")
+		}
+		return &treeNode{Name: "root"}, nil
+	}, WithComponent("tree"), WithDescription("get a tree"))
+
+	Call[int, *treeNode](h, "tree.get", 1)
+	Call[int, *treeNode](h, "tree.get", -1)
+
+	info, ok := h.Describe("tree.get")
+	if !ok {
+		t.Fatal("service not described")
+	}
+	if info.Component != "tree" || info.Description != "get a tree" || info.RequestType != "int" || info.ResponseType != "*servicehub.treeNode" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if info.Calls != 2 || info.Failures != 1 || info.InFlight != 0 || info.LastError != "negative id" || info.LastErrorAt == nil {
+		t.Errorf("unexpected stats: %+v", info)
+	}
+	if info.RequestSchema["type"] != "integer" || info.ResponseSchema["$ref"] == nil {
+		t.Errorf("unexpected schemas: %v %v", info.RequestSchema, info.ResponseSchema)
+	}
+
+	rec := httptest.NewRecorder()
+	h.CatalogHandler()(rec, httptest.NewRequest("GET", "/services", nil))
+	var catalog struct{ Services []ServiceInfo }
+	if err := json.NewDecoder(rec.Body).Decode(&catalog); err != nil || len(catalog.Services) != 1 {
+		t.Errorf("unexpected catalog: %v %+v", err, catalog)
+	}
+	rec = httptest.NewRecorder()
+	h.CatalogHandler()(rec, httptest.NewRequest("GET", "/services?name=missing", nil))
+	if rec.Code != 404 {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
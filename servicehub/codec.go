@@ -0,0 +1,49 @@
+package servicehub
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec 远程调用时请求与响应的编码方式
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSONCodec Codec = jsonCodec{} // 默认编码，便于调试与跨语言调用
+	GobCodec  Codec = gobCodec{}  // 二进制编码，请求与响应为 Go 类型时体积更小
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string                { return "application/json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) ContentType() string { return "application/x-gob" }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// codecFor 按 Content-Type 选择编码，未知类型使用 JSON
+func codecFor(contentType string) Codec {
+	if contentType == GobCodec.ContentType() {
+		return GobCodec
+	}
+	return JSONCodec
+}
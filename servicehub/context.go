@@ -0,0 +1,49 @@
+package servicehub
+
+import "context"
+
+// Caller 调用方身份，随 context 在模块之间传递，字段与 modules.RequestAuth 一致
+type Caller struct {
+	UserId   int64  `json:"user_id"`
+	Username string `json:"username"`
+}
+
+type callerKey struct{}
+
+type traceIDKey struct{}
+
+// WithCaller 返回携带调用方身份的 context
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFrom 获取 context 中的调用方身份
+func CallerFrom(ctx context.Context) (Caller, bool) {
+	if ctx == nil {
+		return Caller{}, false
+	}
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
+
+// WithTraceID 返回携带追踪 ID 的 context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFrom 获取 context 中的追踪 ID，不存在时返回空字符串
+func TraceIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// traceSuffix 日志中附加的追踪 ID
+func traceSuffix(ctx context.Context) string {
+	if traceID := TraceIDFrom(ctx); traceID != "" {
+		return " trace=" + traceID
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+// Package hubtest 提供 ServiceHub 的测试辅助：隔离的服务中心、带期望的模拟服务，
+// 以及将真实调用录制为 JSON 文件并回放
+package hubtest
+
+import (
+	"testing"
+
+	"github.com/gloopai/gloop/servicehub"
+)
+
+/**
+
+func TestOrder(t *testing.T) {
+	// 新的服务中心替换单例，测试结束时恢复，使用 GetHubInstance 的组件只看到本测试的注册
+	hub := hubtest.New(t)
+
+	users := hubtest.NewMock[*UserReq, *User](t, hub, "user.get")
+	users.On(&UserReq{Id: 1}).Return(&User{Id: 1, Name: "alice"}, nil).Times(2)
+	users.OnMatch(func(req *UserReq) bool { return req.Id < 0 }).Return(nil, errors.New("invalid id"))
+
+	runOrderComponent(t)
+	// 测试结束时校验期望的调用次数
+}
+
+*/
+
+// New 创建新的 ServiceHub 并替换单例，测试结束时恢复原单例。
+// 替换的是进程级单例，使用 New 的测试不能调用 t.Parallel
+func New(t testing.TB) *servicehub.ServiceHub {
+	t.Helper()
+	hub := servicehub.NewServiceHub()
+	t.Cleanup(servicehub.SetHubInstance(hub))
+	return hub
+}
@@ -0,0 +1,115 @@
+package hubtest
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/gloopai/gloop/servicehub"
+)
+
+type userReq struct{ Id int }
+type user struct {
+	Id   int
+	Name string
+}
+
+// recorder 记录失败信息而不使外层测试失败
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+func (r *recorder) Cleanup(fn func()) { r.cleanups = append(r.cleanups, fn) }
+func (r *recorder) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestNew_ScopesSingleton(t *testing.T) {
+	outer := servicehub.GetHubInstance()
+	t.Run("scoped", func(t *testing.T) {
+		hub := New(t)
+		if servicehub.GetHubInstance() != hub {
+			t.Fatal("singleton not replaced")
+		}
+		servicehub.RegisterToService("scoped.only", func(req int) (int, error) { return req, nil })
+	})
+	if servicehub.GetHubInstance() != outer || outer.Has("scoped.only") {
+		t.Error("registration leaked out of the scoped hub")
+	}
+}
+
+func TestMock_Expectations(t *testing.T) {
+	hub := servicehub.NewServiceHub()
+	r := &recorder{TB: t}
+	users := NewMock[*userReq, *user](r, hub, "user.get")
+	users.On(&userReq{Id: 1}).Return(&user{Id: 1, Name: "alice"}, nil).Times(2)
+	users.OnMatch(func(req *userReq) bool { return req.Id < 0 }).Return(nil, errors.New("invalid id"))
+	users.On(&userReq{Id: 9}).Once()
+
+	for i := 0; i < 2; i++ {
+		if u, err := servicehub.Call[*userReq, *user](hub, "user.get", &userReq{Id: 1}); err != nil || u.Name != "alice" {
+			t.Fatalf("unexpected mock response %+v, %v", u, err)
+		}
+	}
+	if _, err := servicehub.Call[*userReq, *user](hub, "user.get", &userReq{Id: -1}); err == nil {
+		t.Error("expected mocked error")
+	}
+	var unexpected *ErrUnexpectedCall
+	if _, err := servicehub.Call[*userReq, *user](hub, "user.get", &userReq{Id: 1}); !errors.As(err, &unexpected) {
+		t.Errorf("third call should exceed Times(2), got %v", err)
+	}
+	if len(users.Calls()) != 4 {
+		t.Errorf("expected 4 recorded calls, got %d", len(users.Calls()))
+	}
+
+	r.finish()
+	// 超出次数的调用与未发生的 Id 9 调用
+	if len(r.errors) != 2 {
+		t.Errorf("expected 2 failures, got %q", r.errors)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "users.json")
+
+	// 录制真实服务
+	real := servicehub.NewServiceHub()
+	servicehub.Register(real, "user.get@1.2.0", func(req *userReq) (*user, error) {
+		if req.Id == 0 {
+			return nil, errors.New("not found")
+		}
+		return &user{Id: req.Id, Name: fmt.Sprintf("user-%d", req.Id)}, nil
+	})
+	r := &recorder{TB: t}
+	Record(r, real, path)
+	servicehub.Call[*userReq, *user](real, "user.get@^1", &userReq{Id: 7})
+	servicehub.Call[*userReq, *user](real, "user.get", &userReq{Id: 0})
+	r.finish()
+	if len(r.errors) != 0 {
+		t.Fatal(r.errors)
+	}
+
+	// 回放，不依赖真实服务
+	hub := servicehub.NewServiceHub()
+	r = &recorder{TB: t}
+	Replay[*userReq, *user](r, hub, path, "user.get")
+	if u, err := servicehub.Call[*userReq, *user](hub, "user.get", &userReq{Id: 7}); err != nil || u.Name != "user-7" {
+		t.Errorf("unexpected replay %+v, %v", u, err)
+	}
+	if _, err := servicehub.Call[*userReq, *user](hub, "user.get", &userReq{Id: 0}); err == nil || err.Error() != "not found" {
+		t.Errorf("expected recorded error, got %v", err)
+	}
+	var missing *ErrNoRecording
+	if _, err := servicehub.Call[*userReq, *user](hub, "user.get", &userReq{Id: 8}); !errors.As(err, &missing) || len(r.errors) != 1 {
+		t.Errorf("expected missing recording, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package hubtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gloopai/gloop/servicehub"
+)
+
+// Mock 模拟服务，按添加顺序匹配期望，测试结束时校验调用次数
+type Mock[Req any, Resp any] struct {
+	t    testing.TB
+	name string
+
+	mu           sync.Mutex
+	expectations []*Expectation[Req, Resp]
+	calls        []Req
+}
+
+// Expectation 模拟服务的一项期望
+type Expectation[Req any, Resp any] struct {
+	match func(Req) bool
+	desc  string
+	fn    servicehub.ServiceFuncContext[Req, Resp]
+	times int // 期望的调用次数，0 表示至少一次
+	calls int
+}
+
+// NewMock 以覆盖方式注册模拟服务，没有匹配期望的调用返回 ErrUnexpectedCall 并使测试失败
+func NewMock[Req any, Resp any](t testing.TB, hub *servicehub.ServiceHub, name string, opts ...servicehub.RegisterOption) *Mock[Req, Resp] {
+	t.Helper()
+	m := &Mock[Req, Resp]{t: t, name: name}
+	opts = append([]servicehub.RegisterOption{servicehub.WithOverride()}, opts...)
+	if err := servicehub.RegisterContext(hub, name, m.serve, opts...); err != nil {
+		t.Fatalf("hubtest: register mock '%s' failed: %v", name, err)
+	}
+	t.Cleanup(m.AssertExpectations)
+	return m
+}
+
+// On 期望请求与 req 深度相等的调用
+func (m *Mock[Req, Resp]) On(req Req) *Expectation[Req, Resp] {
+	return m.add(func(r Req) bool { return reflect.DeepEqual(r, req) }, fmt.Sprintf("%+v", req))
+}
+
+// OnMatch 期望请求满足 match 的调用
+func (m *Mock[Req, Resp]) OnMatch(match func(Req) bool) *Expectation[Req, Resp] {
+	return m.add(match, "matching request")
+}
+
+// OnAny 期望任意请求的调用
+func (m *Mock[Req, Resp]) OnAny() *Expectation[Req, Resp] {
+	return m.add(func(Req) bool { return true }, "any request")
+}
+
+func (m *Mock[Req, Resp]) add(match func(Req) bool, desc string) *Expectation[Req, Resp] {
+	e := &Expectation[Req, Resp]{match: match, desc: desc}
+	m.mu.Lock()
+	m.expectations = append(m.expectations, e)
+	m.mu.Unlock()
+	return e
+}
+
+// Return 设置期望的响应
+func (e *Expectation[Req, Resp]) Return(resp Resp, err error) *Expectation[Req, Resp] {
+	e.fn = func(context.Context, Req) (Resp, error) { return resp, err }
+	return e
+}
+
+// Do 由 fn 处理匹配的调用
+func (e *Expectation[Req, Resp]) Do(fn servicehub.ServiceFuncContext[Req, Resp]) *Expectation[Req, Resp] {
+	e.fn = fn
+	return e
+}
+
+// Times 期望恰好调用 n 次，超过后不再匹配
+func (e *Expectation[Req, Resp]) Times(n int) *Expectation[Req, Resp] {
+	e.times = n
+	return e
+}
+
+// Once 期望恰好调用一次
+func (e *Expectation[Req, Resp]) Once() *Expectation[Req, Resp] { return e.Times(1) }
+
+// Calls 返回收到的所有请求
+func (m *Mock[Req, Resp]) Calls() []Req {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Req(nil), m.calls...)
+}
+
+// AssertExpectations 校验每项期望的调用次数，NewMock 会在测试结束时自动调用
+func (m *Mock[Req, Resp]) AssertExpectations() {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			m.t.Errorf("hubtest: service '%s' expected %d call(s) with %s, got %d", m.name, e.times, e.desc, e.calls)
+		case e.times == 0 && e.calls == 0:
+			m.t.Errorf("hubtest: service '%s' expected a call with %s, got none", m.name, e.desc)
+		}
+	}
+}
+
+func (m *Mock[Req, Resp]) serve(ctx context.Context, req Req) (Resp, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, req)
+	var matched *Expectation[Req, Resp]
+	for _, e := range m.expectations {
+		if (e.times == 0 || e.calls < e.times) && e.match(req) {
+			matched = e
+			matched.calls++
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	var zero Resp
+	if matched == nil {
+		m.t.Errorf("hubtest: unexpected call to service '%s' with %+v", m.name, req)
+		return zero, &ErrUnexpectedCall{Name: m.name}
+	}
+	if matched.fn == nil {
+		return zero, nil
+	}
+	return matched.fn(ctx, req)
+}
+
+// ErrUnexpectedCall 模拟服务收到没有匹配期望的调用
+type ErrUnexpectedCall struct{ Name string }
+
+func (e *ErrUnexpectedCall) Error() string {
+	return fmt.Sprintf("unexpected call to mocked service '%s'", e.Name)
+}
@@ -0,0 +1,184 @@
+package hubtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gloopai/gloop/servicehub"
+)
+
+/**
+
+// 首次运行 GLOOP_HUBTEST_RECORD=1 go test ./... 连接真实依赖录制，之后离线回放
+func TestReport(t *testing.T) {
+	hub := hubtest.New(t)
+	if hubtest.Recording() {
+		startUserComponent(t, hub)
+		hubtest.Record(t, hub, "testdata/report.json")
+	} else {
+		hubtest.Replay[*UserReq, *User](t, hub, "testdata/report.json", "user.get")
+	}
+	runReportComponent(t)
+}
+
+*/
+
+// RecordEnv 设置为 1 或 true 时 Recording 返回 true
+const RecordEnv = "GLOOP_HUBTEST_RECORD"
+
+// Recording 判断是否处于录制模式
+func Recording() bool {
+	v := strings.ToLower(os.Getenv(RecordEnv))
+	return v == "1" || v == "true"
+}
+
+// Fixture 录制文件的内容
+type Fixture struct {
+	Calls []RecordedCall `json:"calls"`
+}
+
+// RecordedCall 一次录制的调用
+type RecordedCall struct {
+	Service  string          `json:"service"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Record 在 hub 上添加全局拦截器录制所有调用，测试结束时以 JSON 写入 path。
+// 请求与响应须能编码为 JSON，错误只保留错误信息
+func Record(t testing.TB, hub *servicehub.ServiceHub, path string) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		fixture Fixture
+	)
+	hub.Use(func(ctx context.Context, info *servicehub.CallInfo, req any, next servicehub.Invoker) (any, error) {
+		resp, err := next(ctx, req)
+		call := RecordedCall{Service: info.Service}
+		call.Request, _ = json.Marshal(req)
+		if err != nil {
+			call.Error = err.Error()
+		} else {
+			call.Response, _ = json.Marshal(resp)
+		}
+		mu.Lock()
+		fixture.Calls = append(fixture.Calls, call)
+		mu.Unlock()
+		return resp, err
+	})
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		data, err := json.MarshalIndent(fixture, "", "  ")
+		if err == nil {
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+				err = os.WriteFile(path, append(data, '\n'), 0644)
+			}
+		}
+		if err != nil {
+			t.Errorf("hubtest: write fixture %s failed: %v", path, err)
+		}
+	})
+}
+
+// LoadFixture 读取录制文件
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+// Replay 以覆盖方式注册回放服务，返回 path 中同名服务（不比较版本）的录制结果。
+// 请求按 JSON 编码匹配，相同请求的多次录制依次返回，用尽后重复最后一次；
+// 没有匹配的录制时返回 ErrNoRecording 并使测试失败
+func Replay[Req any, Resp any](t testing.TB, hub *servicehub.ServiceHub, path, name string, opts ...servicehub.RegisterOption) {
+	t.Helper()
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("hubtest: load fixture %s failed: %v", path, err)
+	}
+	base, _, _ := strings.Cut(name, "@")
+	var (
+		mu       sync.Mutex
+		calls    []RecordedCall
+		replayed = make(map[int]bool)
+	)
+	for _, call := range fixture.Calls {
+		if service, _, _ := strings.Cut(call.Service, "@"); service == base {
+			calls = append(calls, call)
+		}
+	}
+
+	serve := func(ctx context.Context, req Req) (Resp, error) {
+		var zero Resp
+		data, err := json.Marshal(req)
+		if err != nil {
+			return zero, err
+		}
+		mu.Lock()
+		index := -1
+		for i, call := range calls {
+			if jsonEqual(call.Request, data) {
+				index = i
+				if !replayed[i] {
+					break
+				}
+			}
+		}
+		if index >= 0 {
+			replayed[index] = true
+		}
+		mu.Unlock()
+
+		if index < 0 {
+			t.Errorf("hubtest: no recording of service '%s' for request %s", name, data)
+			return zero, &ErrNoRecording{Name: name, Request: string(data)}
+		}
+		call := calls[index]
+		if call.Error != "" {
+			return zero, errors.New(call.Error)
+		}
+		var resp Resp
+		if err := json.Unmarshal(call.Response, &resp); err != nil {
+			return zero, err
+		}
+		return resp, nil
+	}
+	opts = append([]servicehub.RegisterOption{servicehub.WithOverride()}, opts...)
+	if err := servicehub.RegisterContext(hub, name, serve, opts...); err != nil {
+		t.Fatalf("hubtest: register replay '%s' failed: %v", name, err)
+	}
+}
+
+// jsonEqual 忽略空白比较两个 JSON 值
+func jsonEqual(a, b []byte) bool {
+	var x, y bytes.Buffer
+	if json.Compact(&x, a) != nil || json.Compact(&y, b) != nil {
+		return false
+	}
+	return bytes.Equal(x.Bytes(), y.Bytes())
+}
+
+// ErrNoRecording 回放时没有匹配请求的录制
+type ErrNoRecording struct {
+	Name    string
+	Request string
+}
+
+func (e *ErrNoRecording) Error() string {
+	return "no recording of service '" + e.Name + "' for request " + e.Request
+}
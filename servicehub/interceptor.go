@@ -0,0 +1,120 @@
+package servicehub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 全局拦截器按添加顺序由外到内执行，服务级拦截器在全局拦截器之后执行
+hub := servicehub.GetHubInstance()
+hub.Use(servicehub.Recovery(), servicehub.Logging(500*time.Millisecond))
+
+servicehub.RegisterToService("user.create", createUser, servicehub.WithInterceptors(servicehub.Validation()))
+
+*/
+
+// CallInfo 一次服务调用的信息
+type CallInfo struct {
+	Service string    // 服务名称
+	Start   time.Time // 调用开始时间
+}
+
+// Interceptor 服务调用拦截器，可在 next 前后检查或修改请求、响应与错误，
+// 不调用 next 时服务函数不会执行
+type Interceptor func(ctx context.Context, info *CallInfo, req any, next Invoker) (any, error)
+
+// Use 添加全局拦截器，对之后的所有调用生效
+func (h *ServiceHub) Use(interceptors ...Interceptor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// 复制后追加，避免影响进行中的调用
+	h.interceptors = append(append([]Interceptor(nil), h.interceptors...), interceptors...)
+}
+
+// WithInterceptors 添加服务级拦截器，在全局拦截器之后执行
+func WithInterceptors(interceptors ...Interceptor) RegisterOption {
+	return func(o *registerOptions) { o.interceptors = append(o.interceptors, interceptors...) }
+}
+
+// chainInterceptors 将拦截器组合为调用链，interceptors[0] 在最外层
+func chainInterceptors(interceptors []Interceptor, info *CallInfo, final Invoker) Invoker {
+	invoke := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoke
+		invoke = func(ctx context.Context, req any) (any, error) {
+			return interceptor(ctx, info, req, next)
+		}
+	}
+	return invoke
+}
+
+// Recovery 捕获服务函数的 panic，记录堆栈并返回 *ErrServicePanic
+func Recovery() Interceptor {
+	return func(ctx context.Context, info *CallInfo, req any, next Invoker) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr, ok := r.(*ErrServicePanic)
+				if !ok {
+					panicErr = &ErrServicePanic{Name: info.Service, Value: r, Stack: debug.Stack()}
+				}
+				lib.Log.Errorf("[ServiceHub] %v%s\n%s", panicErr, traceSuffix(ctx), panicErr.Stack)
+				resp, err = nil, panicErr
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// Logging 记录每次调用的耗时与结果，失败记为错误日志，
+// 耗时超过 slowThreshold 记为警告日志，slowThreshold 为 0 时不检查慢调用
+func Logging(slowThreshold time.Duration) Interceptor {
+	return func(ctx context.Context, info *CallInfo, req any, next Invoker) (any, error) {
+		resp, err := next(ctx, req)
+		duration := time.Since(info.Start)
+		switch {
+		case err != nil:
+			lib.Log.Errorf("[ServiceHub] call %s failed in %s: %v%s", info.Service, duration, err, traceSuffix(ctx))
+		case slowThreshold > 0 && duration >= slowThreshold:
+			lib.Log.Warnf("[ServiceHub] slow call %s took %s%s", info.Service, duration, traceSuffix(ctx))
+		default:
+			lib.Log.Debugf("[ServiceHub] call %s succeeded in %s%s", info.Service, duration, traceSuffix(ctx))
+		}
+		return resp, err
+	}
+}
+
+// Validation 使用 lib.Verification 按 validate 标签校验结构体请求，
+// 校验失败时返回 *ErrServiceValidation 且不调用服务函数
+func Validation() Interceptor {
+	return func(ctx context.Context, info *CallInfo, req any, next Invoker) (any, error) {
+		v := reflect.ValueOf(req)
+		if v.Kind() == reflect.Pointer && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct {
+			if err := lib.Verification.Validator(req); err != nil {
+				return nil, &ErrServiceValidation{Name: info.Service, Err: err}
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// ErrServiceValidation 请求参数校验失败
+type ErrServiceValidation struct {
+	Name string
+	Err  error
+}
+
+func (e *ErrServiceValidation) Error() string {
+	return fmt.Sprintf("service '%s' invalid request: %v", e.Name, e.Err)
+}
+
+func (e *ErrServiceValidation) Unwrap() error { return e.Err }
@@ -0,0 +1,389 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 幂等的查询服务：失败重试，连续失败 5 次后熔断 30 秒，最多 20 个并发，失败时返回缓存
+servicehub.RegisterToService("user.get", getUser, servicehub.WithPolicy(servicehub.Policy{
+	Retry:    &servicehub.RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond},
+	Breaker:  &servicehub.BreakerPolicy{FailureThreshold: 5, OpenTimeout: 30 * time.Second},
+	Bulkhead: &servicehub.BulkheadPolicy{MaxConcurrent: 20},
+	Fallback: servicehub.Fallback(func(ctx context.Context, req *UserReq, err error) (*User, error) {
+		return cache.Get(req.Id)
+	}),
+}))
+
+// 也可以在 container.toml 中配置，覆盖注册时的策略（Fallback 保留）
+[service_policies."user.get".retry]
+max_attempts = 3
+initial_backoff = "100ms"
+
+*/
+
+// Policy 服务调用的弹性策略，作用于同一服务名称的所有提供者与版本，包括远程服务。
+// 执行顺序由外到内：Fallback、Retry、Bulkhead、Breaker
+type Policy struct {
+	Retry    *RetryPolicy    `toml:"retry"`
+	Breaker  *BreakerPolicy  `toml:"breaker"`
+	Bulkhead *BulkheadPolicy `toml:"bulkhead"`
+	Fallback FallbackFunc    `toml:"-"`
+}
+
+// RetryPolicy 失败重试，只应用于幂等的服务，重试间隔按 InitialBackoff 指数增长，不超过 MaxBackoff
+type RetryPolicy struct {
+	MaxAttempts    int              `toml:"max_attempts"`    // 最多尝试次数（含首次），默认 3
+	InitialBackoff time.Duration    `toml:"initial_backoff"` // 首次重试等待时间，默认 100 毫秒
+	MaxBackoff     time.Duration    `toml:"max_backoff"`     // 最长重试等待时间，默认 2 秒
+	RetryIf        func(error) bool `toml:"-"`               // 判断错误是否可重试，默认排除找不到服务、类型不匹配、参数错误、熔断与 panic
+}
+
+// BreakerPolicy 熔断，连续失败 FailureThreshold 次后打开，OpenTimeout 后进入半开状态放行探测调用，
+// 探测成功 SuccessThreshold 次后关闭，失败则重新打开
+type BreakerPolicy struct {
+	FailureThreshold int              `toml:"failure_threshold"`   // 打开熔断的连续失败次数，默认 5
+	OpenTimeout      time.Duration    `toml:"open_timeout"`        // 打开后进入半开状态的时间，默认 30 秒
+	HalfOpenMaxCalls int              `toml:"half_open_max_calls"` // 半开状态同时放行的探测调用数，默认 1
+	SuccessThreshold int              `toml:"success_threshold"`   // 半开状态关闭熔断所需的成功次数，默认 1
+	IsFailure        func(error) bool `toml:"-"`                   // 判断错误是否计为失败，默认排除找不到服务、类型不匹配、参数错误与调用方取消
+}
+
+// BulkheadPolicy 并发限制，超过 MaxConcurrent 的调用最多等待 MaxWait，之后返回 ErrBulkheadFull
+type BulkheadPolicy struct {
+	MaxConcurrent int           `toml:"max_concurrent"`
+	MaxWait       time.Duration `toml:"max_wait"`
+}
+
+// FallbackFunc 调用最终失败时的降级函数，err 为最后一次失败的错误
+type FallbackFunc func(ctx context.Context, req any, err error) (any, error)
+
+// Fallback 将类型化的降级函数转换为 FallbackFunc
+func Fallback[Req any, Resp any](fn func(ctx context.Context, req Req, err error) (Resp, error)) FallbackFunc {
+	return func(ctx context.Context, req any, err error) (any, error) {
+		typedReq, _ := req.(Req)
+		return fn(ctx, typedReq, err)
+	}
+}
+
+// WithPolicy 设置服务的弹性策略
+func WithPolicy(policy Policy) RegisterOption {
+	return func(o *registerOptions) { o.policy = &policy }
+}
+
+// SetPolicy 设置服务的弹性策略，替换原有策略并重置熔断状态，服务可以尚未注册
+func (h *ServiceHub) SetPolicy(name string, policy Policy) {
+	base, _ := splitServiceName(name)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.policies[base] = newPolicyState(base, policy)
+}
+
+// Policy 返回服务的弹性策略
+func (h *ServiceHub) Policy(name string) (Policy, bool) {
+	base, _ := splitServiceName(name)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	state, exists := h.policies[base]
+	if !exists {
+		return Policy{}, false
+	}
+	return state.policy, true
+}
+
+// RemovePolicy 移除服务的弹性策略
+func (h *ServiceHub) RemovePolicy(name string) {
+	base, _ := splitServiceName(name)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.policies, base)
+}
+
+// breakerState 返回服务的熔断状态，未配置熔断时为空
+func (h *ServiceHub) breakerState(base string) BreakerState {
+	h.mu.RLock()
+	state := h.policies[base]
+	h.mu.RUnlock()
+	if state == nil || state.breaker == nil {
+		return ""
+	}
+	return state.breaker.State()
+}
+
+// policyState 策略与其运行状态
+type policyState struct {
+	policy   Policy
+	breaker  *circuitBreaker
+	bulkhead chan struct{}
+}
+
+func newPolicyState(name string, policy Policy) *policyState {
+	state := &policyState{policy: policy}
+	if policy.Breaker != nil {
+		state.breaker = newCircuitBreaker(name, *policy.Breaker)
+	}
+	if policy.Bulkhead != nil && policy.Bulkhead.MaxConcurrent > 0 {
+		state.bulkhead = make(chan struct{}, policy.Bulkhead.MaxConcurrent)
+	}
+	return state
+}
+
+// execute 按策略执行调用，类型不匹配时不使用降级函数
+func (s *policyState) execute(ctx context.Context, name string, req any, call func(context.Context) (any, error)) (any, error) {
+	resp, err := s.retry(ctx, name, call)
+	var typeErr *ErrServiceType
+	if err != nil && s.policy.Fallback != nil && !errors.As(err, &typeErr) {
+		lib.Log.Warnf("[ServiceHub] call %s failed, using fallback: %v%s", name, err, traceSuffix(ctx))
+		return s.policy.Fallback(ctx, req, err)
+	}
+	return resp, err
+}
+
+func (s *policyState) retry(ctx context.Context, name string, call func(context.Context) (any, error)) (any, error) {
+	retry := s.policy.Retry
+	if retry == nil {
+		return s.once(ctx, name, call)
+	}
+	attempts := retry.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	retryIf := retry.RetryIf
+	if retryIf == nil {
+		retryIf = defaultRetryable
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := s.once(ctx, name, call)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !retryIf(err) {
+			return resp, err
+		}
+		backoff := retry.backoff(attempt - 1)
+		lib.Log.Debugf("[ServiceHub] call %s failed (attempt %d/%d), retrying in %s: %v%s", name, attempt, attempts, backoff, err, traceSuffix(ctx))
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 第 retries+1 次重试前的等待时间
+func (p *RetryPolicy) backoff(retries int) time.Duration {
+	backoff, max := p.InitialBackoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 2 * time.Second
+	}
+	for i := 0; i < retries && backoff < max; i++ {
+		backoff *= 2
+	}
+	return min(backoff, max)
+}
+
+// once 在并发限制与熔断保护下执行一次调用
+func (s *policyState) once(ctx context.Context, name string, call func(context.Context) (any, error)) (resp any, err error) {
+	if s.bulkhead != nil {
+		if err := s.acquire(ctx, name); err != nil {
+			return nil, err
+		}
+		defer func() { <-s.bulkhead }()
+	}
+	if s.breaker == nil {
+		return call(ctx)
+	}
+	generation, err := s.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			// panic 计为失败后继续向上抛出
+			s.breaker.record(generation, true)
+		}
+	}()
+	resp, err = call(ctx)
+	completed = true
+	s.breaker.record(generation, s.breaker.isFailure(err))
+	return resp, err
+}
+
+func (s *policyState) acquire(ctx context.Context, name string) error {
+	select {
+	case s.bulkhead <- struct{}{}:
+		return nil
+	default:
+	}
+	if s.policy.Bulkhead.MaxWait <= 0 {
+		return &ErrBulkheadFull{Name: name}
+	}
+	timer := time.NewTimer(s.policy.Bulkhead.MaxWait)
+	defer timer.Stop()
+	select {
+	case s.bulkhead <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return &ErrBulkheadFull{Name: name}
+	case <-ctx.Done():
+		return &ErrServiceTimeout{Name: name, Err: ctx.Err()}
+	}
+}
+
+// isCallerError 由调用方引起的错误，不计为服务失败，也不重试
+func isCallerError(err error) bool {
+	var (
+		notFound   *ErrServiceNotFound
+		typeErr    *ErrServiceType
+		validation *ErrServiceValidation
+	)
+	return errors.As(err, &notFound) || errors.As(err, &typeErr) || errors.As(err, &validation) || errors.Is(err, context.Canceled)
+}
+
+func defaultRetryable(err error) bool {
+	var (
+		open     *ErrCircuitOpen
+		panicErr *ErrServicePanic
+	)
+	return !isCallerError(err) && !errors.As(err, &open) && !errors.As(err, &panicErr)
+}
+
+// BreakerState 熔断状态
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+type circuitBreaker struct {
+	name   string
+	policy BreakerPolicy
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int // 关闭状态下的连续失败次数
+	successes int // 半开状态下的成功次数
+	probes    int // 半开状态下进行中的探测调用
+	openedAt  time.Time
+	// generation 每次状态切换时递增，放行时记录，结果只计入放行时所在的状态
+	generation uint64
+}
+
+func newCircuitBreaker(name string, policy BreakerPolicy) *circuitBreaker {
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = 5
+	}
+	if policy.OpenTimeout <= 0 {
+		policy.OpenTimeout = 30 * time.Second
+	}
+	if policy.HalfOpenMaxCalls <= 0 {
+		policy.HalfOpenMaxCalls = 1
+	}
+	if policy.SuccessThreshold <= 0 {
+		policy.SuccessThreshold = 1
+	}
+	return &circuitBreaker{name: name, policy: policy, state: BreakerClosed}
+}
+
+// State 返回当前熔断状态
+func (b *circuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *circuitBreaker) isFailure(err error) bool {
+	if b.policy.IsFailure != nil {
+		return b.policy.IsFailure(err)
+	}
+	return err != nil && !isCallerError(err)
+}
+
+// allow 判断是否放行调用，返回放行时的状态代数；打开状态超过 OpenTimeout 后进入半开状态
+func (b *circuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen {
+		if time.Since(b.openedAt) < b.policy.OpenTimeout {
+			return 0, &ErrCircuitOpen{Name: b.name}
+		}
+		b.transition(BreakerHalfOpen)
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probes >= b.policy.HalfOpenMaxCalls {
+			return 0, &ErrCircuitOpen{Name: b.name}
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// record 记录放行调用的结果，放行后状态已切换的调用（如熔断前放行的慢调用）结果被忽略
+func (b *circuitBreaker) record(generation uint64, failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case BreakerClosed:
+		if !failure {
+			b.failures = 0
+			return
+		}
+		if b.failures++; b.failures >= b.policy.FailureThreshold {
+			b.transition(BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		b.probes--
+		if failure {
+			b.transition(BreakerOpen)
+			return
+		}
+		if b.successes++; b.successes >= b.policy.SuccessThreshold {
+			b.transition(BreakerClosed)
+		}
+	}
+}
+
+// transition 切换状态并记录日志，调用方持有锁
+func (b *circuitBreaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.failures, b.successes, b.probes = 0, 0, 0
+	if to == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+	if to == BreakerClosed {
+		lib.Log.Infof("[ServiceHub] circuit breaker of service '%s' %s -> %s", b.name, from, to)
+	} else {
+		lib.Log.Warnf("[ServiceHub] circuit breaker of service '%s' %s -> %s", b.name, from, to)
+	}
+}
+
+// ErrCircuitOpen 熔断打开，调用被拒绝
+type ErrCircuitOpen struct{ Name string }
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("service '%s' circuit breaker is open", e.Name)
+}
+
+// ErrBulkheadFull 并发数达到上限，调用被拒绝
+type ErrBulkheadFull struct{ Name string }
+
+func (e *ErrBulkheadFull) Error() string {
+	return fmt.Sprintf("service '%s' concurrency limit reached", e.Name)
+}
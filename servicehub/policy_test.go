@@ -0,0 +1,187 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestPolicy_RetrySucceedsAfterFailures(t *testing.T) {
+	h := NewServiceHub()
+	var calls atomic.Int32
+	err := Register(h, "flaky", func(req int) (int, error) {
+		if calls.Add(1) < 3 {
+			return 0, errFlaky
+		}
+		return req * 2, nil
+	}, WithPolicy(Policy{Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := Call[int, int](h, "flaky", 21)
+	if err != nil || resp != 42 {
+		t.Fatalf("expected 42 after retries, got %d, %v", resp, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	// 调用方错误不重试
+	calls.Store(0)
+	if _, err := Call[string, int](h, "flaky", "x"); err == nil {
+		t.Fatal("expected type error")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("type mismatch should not reach the service, got %d calls", calls.Load())
+	}
+}
+
+func TestPolicy_BreakerOpensAndRecovers(t *testing.T) {
+	h := NewServiceHub()
+	var failing atomic.Bool
+	failing.Store(true)
+	err := Register(h, "fragile", func(req int) (int, error) {
+		if failing.Load() {
+			return 0, errFlaky
+		}
+		return req, nil
+	}, WithPolicy(Policy{Breaker: &BreakerPolicy{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := Call[int, int](h, "fragile", 1); !errors.Is(err, errFlaky) {
+			t.Fatalf("expected service error, got %v", err)
+		}
+	}
+	var open *ErrCircuitOpen
+	if _, err := Call[int, int](h, "fragile", 1); !errors.As(err, &open) {
+		t.Fatalf("expected circuit open, got %v", err)
+	}
+	if info, _ := h.Describe("fragile"); info.Breaker != BreakerOpen {
+		t.Errorf("expected breaker open in catalog, got %q", info.Breaker)
+	}
+
+	failing.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := Call[int, int](h, "fragile", 1); err != nil {
+		t.Fatalf("half-open probe should pass, got %v", err)
+	}
+	if info, _ := h.Describe("fragile"); info.Breaker != BreakerClosed {
+		t.Errorf("expected breaker closed after probe, got %q", info.Breaker)
+	}
+}
+
+func TestPolicy_BreakerIgnoresCallsFromEarlierState(t *testing.T) {
+	h := NewServiceHub()
+	slow := make(chan struct{})
+	probe := make(chan struct{})
+	entered := make(chan int, 2)
+	err := Register(h, "straddle", func(req int) (int, error) {
+		switch req {
+		case 0: // 熔断前放行的慢调用
+			entered <- req
+			<-slow
+			return req, nil
+		case 1:
+			return 0, errFlaky
+		default: // 半开探测
+			entered <- req
+			<-probe
+			return 0, errFlaky
+		}
+	}, WithPolicy(Policy{Breaker: &BreakerPolicy{FailureThreshold: 2, OpenTimeout: 30 * time.Millisecond}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slowDone := make(chan error)
+	go func() {
+		_, err := Call[int, int](h, "straddle", 0)
+		slowDone <- err
+	}()
+	<-entered
+	for i := 0; i < 2; i++ {
+		Call[int, int](h, "straddle", 1)
+	}
+	time.Sleep(40 * time.Millisecond)
+
+	probeDone := make(chan error)
+	go func() {
+		_, err := Call[int, int](h, "straddle", 2)
+		probeDone <- err
+	}()
+	<-entered
+
+	// 慢调用在半开状态下成功返回，不应占用探测名额或关闭熔断
+	close(slow)
+	if err := <-slowDone; err != nil {
+		t.Fatalf("slow call should succeed, got %v", err)
+	}
+	if info, _ := h.Describe("straddle"); info.Breaker != BreakerHalfOpen {
+		t.Fatalf("expected breaker still half-open, got %q", info.Breaker)
+	}
+	var open *ErrCircuitOpen
+	if _, err := Call[int, int](h, "straddle", 1); !errors.As(err, &open) {
+		t.Fatalf("expected probe slot still taken, got %v", err)
+	}
+
+	close(probe)
+	<-probeDone
+	if info, _ := h.Describe("straddle"); info.Breaker != BreakerOpen {
+		t.Errorf("expected failed probe to reopen breaker, got %q", info.Breaker)
+	}
+}
+
+func TestPolicy_BulkheadAndFallback(t *testing.T) {
+	h := NewServiceHub()
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	err := Register(h, "slow", func(req int) (int, error) {
+		entered <- struct{}{}
+		<-release
+		return req, nil
+	}, WithPolicy(Policy{Bulkhead: &BulkheadPolicy{MaxConcurrent: 1}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := Call[int, int](h, "slow", 1)
+		done <- err
+	}()
+	<-entered
+
+	var full *ErrBulkheadFull
+	if _, err := Call[int, int](h, "slow", 2); !errors.As(err, &full) {
+		t.Fatalf("expected bulkhead full, got %v", err)
+	}
+
+	h.SetPolicy("slow", Policy{
+		Bulkhead: &BulkheadPolicy{MaxConcurrent: 1},
+		Fallback: Fallback(func(ctx context.Context, req int, err error) (int, error) { return -req, nil }),
+	})
+	// 新策略的并发计数独立，先占满再验证降级
+	go func() {
+		_, err := Call[int, int](h, "slow", 3)
+		done <- err
+	}()
+	<-entered
+	if resp, err := Call[int, int](h, "slow", 4); err != nil || resp != -4 {
+		t.Fatalf("expected fallback -4, got %d, %v", resp, err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package servicehub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 节点 A 通过 HTTP 提供本地服务
+http.ListenAndServe(":7001", servicehub.GetHubInstance().Handler("secret"))
+
+// 节点 B 添加节点 A 后，本地不存在的服务会转发到节点 A，调用方式不变
+hub := servicehub.GetHubInstance()
+hub.AddPeer(ctx, "node-a", "http://10.0.0.2:7001", servicehub.WithPeerToken("secret"))
+user, err := servicehub.CallFromServiceContext[*model.UserReq, *model.User](ctx, "user.get", req)
+
+*/
+
+const (
+	remoteCatalogPath = "/servicehub/catalog"
+	remoteCallPath    = "/servicehub/call/"
+
+	headerTraceID      = "X-Trace-Id"
+	headerCaller       = "X-Gloop-Caller"
+	headerCallerSign   = "X-Gloop-Caller-Signature" // 调用方身份的 HMAC-SHA256 签名，以令牌为密钥
+	headerTimeout      = "X-Gloop-Timeout"          // 调用方剩余时间，毫秒
+	headerRequestType  = "X-Gloop-Request-Type"
+	headerResponseType = "X-Gloop-Response-Type"
+
+	defaultPeerTimeout     = 10 * time.Second
+	peerRefreshMinInterval = time.Second // 找不到服务时刷新远程目录的最小间隔
+	maxRemoteRequestBytes  = 8 << 20     // 远程调用请求体的最大长度
+)
+
+// peer 远程节点
+type peer struct {
+	nodeID  string
+	address string
+	codec   Codec
+	token   string
+	timeout time.Duration
+	client  *http.Client
+
+	mu        sync.RWMutex
+	services  map[string][]ServiceInfo // 按不含版本的服务名称索引
+	refreshed time.Time
+}
+
+// PeerOption 远程节点选项
+type PeerOption func(*peer)
+
+// WithCodec 设置远程调用的编码，默认 JSONCodec
+func WithCodec(codec Codec) PeerOption {
+	return func(p *peer) { p.codec = codec }
+}
+
+// WithPeerTimeout 设置远程调用的超时时间，默认 10 秒
+func WithPeerTimeout(timeout time.Duration) PeerOption {
+	return func(p *peer) { p.timeout = timeout }
+}
+
+// WithPeerToken 设置访问远程节点的令牌，对应远程节点 Handler 的 token，调用方身份以该令牌签名后传递
+func WithPeerToken(token string) PeerOption {
+	return func(p *peer) { p.token = token }
+}
+
+// AddPeer 添加远程节点并获取其服务目录，address 为远程节点 Handler 的地址，如 "http://10.0.0.2:7001"。
+// 同一 nodeID 会被替换；获取目录失败时返回错误，但节点仍被保留，之后调用时会重新获取
+func (h *ServiceHub) AddPeer(ctx context.Context, nodeID, address string, opts ...PeerOption) error {
+	p := &peer{
+		nodeID:  nodeID,
+		address: strings.TrimRight(address, "/"),
+		codec:   JSONCodec,
+		timeout: defaultPeerTimeout,
+		client:  &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	h.mu.Lock()
+	peers := make([]*peer, 0, len(h.peers)+1)
+	for _, existing := range h.peers {
+		if existing.nodeID != nodeID {
+			peers = append(peers, existing)
+		}
+	}
+	h.peers = append(peers, p)
+	h.mu.Unlock()
+
+	lib.Log.Infof("[ServiceHub] add peer %s at %s", nodeID, p.address)
+	return p.refresh(ctx)
+}
+
+// RemovePeer 移除远程节点
+func (h *ServiceHub) RemovePeer(nodeID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	peers := make([]*peer, 0, len(h.peers))
+	for _, existing := range h.peers {
+		if existing.nodeID != nodeID {
+			peers = append(peers, existing)
+		}
+	}
+	h.peers = peers
+}
+
+// RefreshPeers 重新获取所有远程节点的服务目录
+func (h *ServiceHub) RefreshPeers(ctx context.Context) error {
+	h.mu.RLock()
+	peers := h.peers
+	h.mu.RUnlock()
+	var errs []error
+	for _, p := range peers {
+		if err := p.refresh(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remoteEntry 在远程节点目录中查找服务，找不到时刷新过期的目录后重试
+func (h *ServiceHub) remoteEntry(ctx context.Context, name string, reqType, respType reflect.Type) (*serviceEntry, error) {
+	h.mu.RLock()
+	peers := h.peers
+	h.mu.RUnlock()
+
+	p, info, err := findPeer(peers, name, reqType.String(), respType.String())
+	var notFound *ErrServiceNotFound
+	if errors.As(err, &notFound) {
+		for _, candidate := range peers {
+			if candidate.stale() {
+				candidate.refresh(ctx)
+			}
+		}
+		p, info, err = findPeer(peers, name, reqType.String(), respType.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &serviceEntry{
+		invoke:      p.invoker(info.fullName(), reqType, respType),
+		reqType:     reqType,
+		respType:    respType,
+		description: info.Description,
+		timeout:     p.timeout,
+	}, nil
+}
+
+// findPeer 在远程节点目录中查找版本范围内类型匹配的最高版本，多个节点提供相同版本时取先添加的节点
+func findPeer(peers []*peer, name, reqType, respType string) (*peer, ServiceInfo, error) {
+	base, version := splitServiceName(name)
+	versionRange, err := ParseVersionRange(version)
+	if err != nil {
+		return nil, ServiceInfo{}, err
+	}
+	var best *peer
+	var bestInfo ServiceInfo
+	typeMismatch := false
+	for _, p := range peers {
+		p.mu.RLock()
+		infos := p.services[base]
+		p.mu.RUnlock()
+		for _, info := range infos {
+			v := info.entryVersion()
+			if version != "" && (!v.versioned || !versionRange.Contains(v.version)) {
+				continue
+			}
+			if info.Stream || info.RequestType != reqType || info.ResponseType != respType {
+				typeMismatch = true
+				continue
+			}
+			if best == nil || compareEntryVersion(v, bestInfo.entryVersion()) > 0 {
+				best, bestInfo = p, info
+			}
+		}
+	}
+	if best == nil {
+		if typeMismatch {
+			return nil, ServiceInfo{}, &ErrServiceType{name}
+		}
+		return nil, ServiceInfo{}, &ErrServiceNotFound{name}
+	}
+	return best, bestInfo, nil
+}
+
+func (p *peer) stale() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return time.Since(p.refreshed) >= peerRefreshMinInterval
+}
+
+// refresh 获取远程节点的服务目录
+func (p *peer) refresh(ctx context.Context) error {
+	p.mu.Lock()
+	p.refreshed = time.Now()
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+remoteCatalogPath, nil)
+	if err != nil {
+		return err
+	}
+	p.authorize(req)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch service catalog of peer '%s' failed: %w", p.nodeID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch service catalog of peer '%s' failed: %s", p.nodeID, resp.Status)
+	}
+	var catalog struct {
+		Services []ServiceInfo `json:"services"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return fmt.Errorf("decode service catalog of peer '%s' failed: %w", p.nodeID, err)
+	}
+
+	services := make(map[string][]ServiceInfo, len(catalog.Services))
+	for _, info := range catalog.Services {
+		services[info.Name] = append(services[info.Name], info)
+	}
+	p.mu.Lock()
+	p.services = services
+	p.mu.Unlock()
+	lib.Log.Debugf("[ServiceHub] peer %s provides %d services", p.nodeID, len(services))
+	return nil
+}
+
+func (p *peer) authorize(req *http.Request) {
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+}
+
+// invoker 返回通过 HTTP 调用远程服务的服务函数
+func (p *peer) invoker(name string, reqType, respType reflect.Type) Invoker {
+	return func(ctx context.Context, req any) (any, error) {
+		body, err := p.codec.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("encode request of service '%s' failed: %w", name, err)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.address+remoteCallPath+url.PathEscape(name), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", p.codec.ContentType())
+		httpReq.Header.Set(headerRequestType, reqType.String())
+		httpReq.Header.Set(headerResponseType, respType.String())
+		if traceID := TraceIDFrom(ctx); traceID != "" {
+			httpReq.Header.Set(headerTraceID, traceID)
+		}
+		if caller, ok := CallerFrom(ctx); ok && p.token != "" {
+			if data, err := json.Marshal(caller); err == nil {
+				httpReq.Header.Set(headerCaller, string(data))
+				httpReq.Header.Set(headerCallerSign, signCaller(p.token, name, data))
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			httpReq.Header.Set(headerTimeout, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+		}
+		p.authorize(httpReq)
+
+		resp, err := p.client.Do(httpReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, &ErrServiceTimeout{Name: name, Err: ctx.Err()}
+			}
+			return nil, &ErrRemoteService{Node: p.nodeID, Name: name, Message: err.Error()}
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, &ErrRemoteService{Node: p.nodeID, Name: name, Message: err.Error()}
+		}
+		if resp.StatusCode != http.StatusOK {
+			var remoteErr remoteError
+			if err := json.Unmarshal(data, &remoteErr); err != nil || remoteErr.Code == "" {
+				return nil, &ErrRemoteService{Node: p.nodeID, Name: name, Message: resp.Status}
+			}
+			if remoteErr.Code == errCodeNotFound {
+				// 远程服务已注销，下次调用时重新获取目录
+				base, _ := splitServiceName(name)
+				p.mu.Lock()
+				delete(p.services, base)
+				p.mu.Unlock()
+			}
+			return nil, remoteErr.toError(p.nodeID, name)
+		}
+
+		out := reflect.New(respType)
+		if err := codecFor(resp.Header.Get("Content-Type")).Unmarshal(data, out.Interface()); err != nil {
+			return nil, fmt.Errorf("decode response of service '%s' failed: %w", name, err)
+		}
+		return out.Elem().Interface(), nil
+	}
+}
+
+// Handler 返回供远程节点调用本地服务的 HTTP 处理器，提供服务目录 /servicehub/catalog 与调用入口 /servicehub/call/{name}。
+// 请求必须携带 Authorization: Bearer <token>，token 为空时拒绝所有请求
+func (h *ServiceHub) Handler(token string) http.Handler {
+	if token == "" {
+		lib.Log.Warnf("[ServiceHub] remote handler has no token, all requests will be rejected")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(remoteCatalogPath, h.CatalogHandler())
+	mux.HandleFunc(remoteCallPath, func(w http.ResponseWriter, r *http.Request) { h.serveCall(w, r, token) })
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !validBearer(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// validBearer 以常量时间比较请求携带的令牌，token 为空时总是返回 false
+func validBearer(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// signCaller 调用节点以令牌对服务名称与调用方身份签名，签名绑定服务名称，不能用于调用其他服务
+func signCaller(token, name string, caller []byte) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(caller)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// callerFromRequest 读取调用节点签名的调用方身份，未签名或签名不符时忽略
+func callerFromRequest(r *http.Request, token, name string) (Caller, bool) {
+	data := r.Header.Get(headerCaller)
+	if data == "" {
+		return Caller{}, false
+	}
+	if !hmac.Equal([]byte(r.Header.Get(headerCallerSign)), []byte(signCaller(token, name, []byte(data)))) {
+		lib.Log.Warnf("[ServiceHub] ignore unsigned caller of remote call '%s' from %s", name, r.RemoteAddr)
+		return Caller{}, false
+	}
+	var caller Caller
+	if err := json.Unmarshal([]byte(data), &caller); err != nil {
+		return Caller{}, false
+	}
+	return caller, true
+}
+
+// serveCall 处理远程调用，只调用本地服务，避免在节点之间循环转发
+func (h *ServiceHub) serveCall(w http.ResponseWriter, r *http.Request, token string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, remoteCallPath)
+	reqType, respType := r.Header.Get(headerRequestType), r.Header.Get(headerResponseType)
+	entry, err := h.resolve(r.Context(), name, func(e *serviceEntry) bool {
+		return !e.stream && e.reqType.String() == reqType && e.respType.String() == respType
+	})
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	if traceID := r.Header.Get(headerTraceID); traceID != "" {
+		ctx = WithTraceID(ctx, traceID)
+	}
+	if caller, ok := callerFromRequest(r, token, name); ok {
+		ctx = WithCaller(ctx, caller)
+	}
+	if ms, err := strconv.ParseInt(r.Header.Get(headerTimeout), 10, 64); err == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+		defer cancel()
+	}
+
+	codec := codecFor(r.Header.Get("Content-Type"))
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRemoteRequestBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeRemoteError(w, err)
+		return
+	}
+	in := reflect.New(entry.reqType)
+	if err := codec.Unmarshal(body, in.Interface()); err != nil {
+		writeRemoteError(w, &ErrServiceValidation{Name: name, Err: err})
+		return
+	}
+
+	resp, err := h.invokeRemote(ctx, name, entry, in.Elem().Interface())
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	out, err := codec.Marshal(resp)
+	if err != nil {
+		writeRemoteError(w, fmt.Errorf("encode response failed: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", codec.ContentType())
+	w.Write(out)
+}
+
+// invokeRemote 调用本地服务，panic 转换为错误返回给远程节点
+func (h *ServiceHub) invokeRemote(ctx context.Context, name string, entry *serviceEntry, req any) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, ok := r.(*ErrServicePanic)
+			if !ok {
+				panicErr = &ErrServicePanic{Name: name, Value: r}
+			}
+			lib.Log.Errorf("[ServiceHub] remote call %v%s\n%s", panicErr, traceSuffix(ctx), panicErr.Stack)
+			resp, err = nil, panicErr
+		}
+	}()
+	return h.invokeEntry(ctx, name, entry, req)
+}
+
+// 远程错误码，用于在节点之间还原错误类型
+const (
+	errCodeNotFound = "not_found"
+	errCodeType     = "type_mismatch"
+	errCodeTimeout  = "timeout"
+	errCodeCanceled = "canceled"
+	errCodeInvalid  = "invalid_request"
+	errCodeInternal = "internal"
+)
+
+type remoteError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeRemoteError(w http.ResponseWriter, err error) {
+	status, remoteErr := http.StatusInternalServerError, remoteError{Code: errCodeInternal, Message: err.Error()}
+	var (
+		notFound   *ErrServiceNotFound
+		typeErr    *ErrServiceType
+		timeout    *ErrServiceTimeout
+		validation *ErrServiceValidation
+	)
+	switch {
+	case errors.As(err, &notFound):
+		status, remoteErr.Code = http.StatusNotFound, errCodeNotFound
+	case errors.As(err, &typeErr):
+		status, remoteErr.Code = http.StatusConflict, errCodeType
+	case errors.As(err, &timeout) && errors.Is(err, context.Canceled):
+		status, remoteErr.Code = http.StatusRequestTimeout, errCodeCanceled
+	case errors.As(err, &timeout):
+		status, remoteErr.Code = http.StatusGatewayTimeout, errCodeTimeout
+	case errors.As(err, &validation):
+		status, remoteErr.Code, remoteErr.Message = http.StatusBadRequest, errCodeInvalid, validation.Err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(remoteErr)
+}
+
+// toError 将远程错误还原为本地错误类型
+func (e remoteError) toError(nodeID, name string) error {
+	switch e.Code {
+	case errCodeNotFound:
+		return &ErrServiceNotFound{name}
+	case errCodeType:
+		return &ErrServiceType{name}
+	case errCodeTimeout:
+		return &ErrServiceTimeout{Name: name, Err: context.DeadlineExceeded}
+	case errCodeCanceled:
+		return &ErrServiceTimeout{Name: name, Err: context.Canceled}
+	case errCodeInvalid:
+		return &ErrServiceValidation{Name: name, Err: errors.New(e.Message)}
+	default:
+		return &ErrRemoteService{Node: nodeID, Name: name, Message: e.Message}
+	}
+}
+
+// ErrRemoteService 远程节点上的服务返回错误或无法访问，Message 为远程错误信息
+type ErrRemoteService struct {
+	Node    string
+	Name    string
+	Message string
+}
+
+func (e *ErrRemoteService) Error() string {
+	return fmt.Sprintf("service '%s' on node '%s' failed: %s", e.Name, e.Node, e.Message)
+}
@@ -0,0 +1,137 @@
+package servicehub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRemotePair 在回环地址上启动提供服务的节点 a，并返回添加了节点 a 的节点 b
+func newRemotePair(t *testing.T, opts ...PeerOption) (a, b *ServiceHub) {
+	a, b = NewServiceHub(), NewServiceHub()
+	server := httptest.NewServer(a.Handler("secret"))
+	t.Cleanup(server.Close)
+
+	RegisterContext(a, "echo", func(ctx context.Context, req *echoReq) (*echoResp, error) {
+		caller, _ := CallerFrom(ctx)
+		return &echoResp{Text: req.Text, UserId: caller.UserId, Trace: TraceIDFrom(ctx)}, nil
+	})
+	RegisterContext(a, "slow", func(ctx context.Context, req int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	Register(a, "create", func(req *createReq) (string, error) { return req.Name, nil },
+		WithInterceptors(Validation()))
+
+	opts = append([]PeerOption{WithPeerToken("secret")}, opts...)
+	if err := b.AddPeer(context.Background(), "a", server.URL, opts...); err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+func TestRemote_Call(t *testing.T) {
+	for name, codec := range map[string]Codec{"json": JSONCodec, "gob": GobCodec} {
+		t.Run(name, func(t *testing.T) {
+			_, b := newRemotePair(t, WithCodec(codec))
+			ctx := WithTraceID(WithCaller(context.Background(), Caller{UserId: 9}), "t-9")
+			resp, err := CallContext[*echoReq, *echoResp](ctx, b, "echo", &echoReq{Text: "over the wire"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != "over the wire" || resp.UserId != 9 || resp.Trace != "t-9" {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestRemote_ErrorMapping(t *testing.T) {
+	a, b := newRemotePair(t)
+
+	var typeErr *ErrServiceType
+	if _, err := Call[string, string](b, "echo", "x"); !errors.As(err, &typeErr) {
+		t.Errorf("expected type mismatch, got %v", err)
+	}
+	var notFound *ErrServiceNotFound
+	if _, err := Call[int, int](b, "missing", 1); !errors.As(err, &notFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	var validation *ErrServiceValidation
+	if _, err := Call[*createReq, string](b, "create", &createReq{}); !errors.As(err, &validation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var timeout *ErrServiceTimeout
+	if _, err := CallContext[int, int](ctx, b, "slow", 1); !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+
+	// 远程服务注销后返回 ErrServiceNotFound
+	a.Unregister("echo")
+	if _, err := Call[*echoReq, *echoResp](b, "echo", &echoReq{}); !errors.As(err, &notFound) {
+		t.Errorf("expected not found after unregister, got %v", err)
+	}
+}
+
+func TestRemote_Unauthorized(t *testing.T) {
+	a := NewServiceHub()
+	server := httptest.NewServer(a.Handler("secret"))
+	defer server.Close()
+	if err := NewServiceHub().AddPeer(context.Background(), "a", server.URL, WithPeerToken("wrong")); err == nil {
+		t.Error("expected unauthorized peer to fail")
+	}
+}
+
+func TestRemote_RequiresToken(t *testing.T) {
+	server := httptest.NewServer(NewServiceHub().Handler(""))
+	defer server.Close()
+	if err := NewServiceHub().AddPeer(context.Background(), "a", server.URL); err == nil {
+		t.Error("expected a handler without token to reject all requests")
+	}
+}
+
+func TestRemote_IgnoresUnsignedCaller(t *testing.T) {
+	a := NewServiceHub()
+	RegisterContext(a, "echo", func(ctx context.Context, req *echoReq) (*echoResp, error) {
+		caller, _ := CallerFrom(ctx)
+		return &echoResp{UserId: caller.UserId}, nil
+	})
+	server := httptest.NewServer(a.Handler("secret"))
+	defer server.Close()
+
+	call := func(header http.Header, body string) (*http.Response, echoResp) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+remoteCallPath+"echo", strings.NewReader(body))
+		req.Header = header
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set(headerRequestType, "*servicehub.echoReq")
+		req.Header.Set(headerResponseType, "*servicehub.echoResp")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out echoResp
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+
+	caller := `{"user_id":1}`
+	if _, out := call(http.Header{headerCaller: {caller}}, `{}`); out.UserId != 0 {
+		t.Errorf("unsigned caller should be ignored, got user %d", out.UserId)
+	}
+	signed := http.Header{headerCaller: {caller}, headerCallerSign: {signCaller("secret", "echo", []byte(caller))}}
+	if _, out := call(signed, `{}`); out.UserId != 1 {
+		t.Errorf("signed caller should be accepted, got user %d", out.UserId)
+	}
+	if resp, _ := call(http.Header{}, `{"text":"`+strings.Repeat("x", maxRemoteRequestBytes)+`"}`); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,176 @@
+package servicehub
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchema 按 encoding/json 的编码规则生成类型的 JSON Schema（draft 2020-12）。
+// 具名结构体放入 $defs 并通过 $ref 引用，支持递归类型；
+// 字段名取 json 标签，validate 标签包含 required 的字段列入 required
+func JSONSchema(t reflect.Type) map[string]any {
+	g := &schemaGenerator{defs: map[string]any{}, names: map[reflect.Type]string{}}
+	schema := g.schema(t)
+	if schema == nil {
+		schema = map[string]any{}
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+var (
+	timeType           = reflect.TypeFor[time.Time]()
+	durationType       = reflect.TypeFor[time.Duration]()
+	rawMessageType     = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType  = reflect.TypeFor[json.Marshaler]()
+	schemaDefNameClean = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+)
+
+type schemaGenerator struct {
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+// schema 返回类型的 Schema，不能编码为 JSON 的类型返回 nil
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "duration in nanoseconds"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && t.Implements(jsonMarshalerType) {
+		// 自定义编码的类型无法推断结构
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		items := g.schema(t.Elem())
+		if items == nil {
+			return nil
+		}
+		schema := map[string]any{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			schema["minItems"], schema["maxItems"] = t.Len(), t.Len()
+		}
+		return schema
+	case reflect.Map:
+		values := g.schema(t.Elem())
+		if values == nil {
+			return nil
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.defName(t)
+			g.names[t] = name
+			g.defs[name] = map[string]any{} // 占位，避免嵌套类型使用相同名称
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	default:
+		// chan、func、complex 等类型不能编码为 JSON
+		return nil
+	}
+}
+
+// defName 返回 $defs 中的名称，不同包的同名类型加以区分
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	name := schemaDefNameClean.ReplaceAllString(t.String(), "_")
+	for i := 2; ; i++ {
+		if _, exists := g.defs[name]; !exists {
+			return name
+		}
+		name = schemaDefNameClean.ReplaceAllString(t.String(), "_") + "_" + strconv.Itoa(i)
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	g.collectFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// collectFields 收集结构体字段，匿名嵌入且没有 json 名称的结构体字段展开到外层
+func (g *schemaGenerator) collectFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.collectFields(fieldType, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := g.schema(fieldType)
+		if schema == nil {
+			continue
+		}
+		if strings.Contains(opts, "string") && schema["type"] != nil {
+			schema = map[string]any{"type": "string"}
+		}
+		properties[name] = schema
+		if hasRule(field.Tag.Get("validate"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func hasRule(rules, rule string) bool {
+	for _, r := range strings.Split(rules, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,15 @@
 package servicehub
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gloopai/gloop/lib"
 )
@@ -26,69 +34,190 @@ if servicehub.GetHubInstance().Has(webhookconst.SERVICE_BOT_INFO) {
 		}
 	}
 
+// 支持 context 的服务，ctx 携带调用方身份与截止时间
+servicehub.RegisterToServiceContext("user.get", func(ctx context.Context, req *model.UserReq) (*model.User, error) {
+	caller, _ := servicehub.CallerFrom(ctx)
+	return loadUser(ctx, caller.UserId, req.Id)
+}, servicehub.WithTimeout(3*time.Second))
+
+ctx, cancel := context.WithTimeout(payload.Context(), time.Second)
+defer cancel()
+user, err := servicehub.CallFromServiceContext[*model.UserReq, *model.User](ctx, "user.get", &model.UserReq{Id: 1})
+
 */
 
-var (
-	singleton     *ServiceHub
-	singletonOnce sync.Once
-)
+var singleton atomic.Pointer[ServiceHub]
 
 // GetHubInstance 获取 ServiceHub 单例
 func GetHubInstance() *ServiceHub {
-	singletonOnce.Do(func() {
-		singleton = NewServiceHub()
-	})
-	return singleton
+	if h := singleton.Load(); h != nil {
+		return h
+	}
+	singleton.CompareAndSwap(nil, NewServiceHub())
+	return singleton.Load()
+}
+
+// SetHubInstance 替换 ServiceHub 单例，返回恢复原单例的函数。
+// 用于测试或容器之间隔离服务注册，替换前获取的单例引用不受影响
+func SetHubInstance(h *ServiceHub) (restore func()) {
+	previous := singleton.Swap(h)
+	return func() { singleton.Store(previous) }
 }
 
 // ServiceFunc 定义服务函数的类型，使用泛型提升类型安全
 type ServiceFunc[Req any, Resp any] func(req Req) (Resp, error)
 
+// ServiceFuncContext 支持 context 的服务函数，ctx 携带调用方身份、追踪 ID 与截止时间
+type ServiceFuncContext[Req any, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// Invoker 擦除类型后的服务函数，也是拦截器链中的下一个处理器
+type Invoker func(ctx context.Context, req any) (any, error)
+
 // ServiceHub 用于模块间注册和调用服务
 // 支持并发安全
 
 // ServiceHub 支持不同类型服务的注册和调用
 type serviceEntry struct {
-	fn          any
-	description string
+	invoke       Invoker
+	reqType      reflect.Type
+	respType     reflect.Type
+	description  string
+	timeout      time.Duration // 单次调用超时时间，0 表示仅受调用方 ctx 限制
+	interceptors []Interceptor // 服务级拦截器，在全局拦截器之后执行
+	component    string        // 注册服务的组件
+	stats        *serviceStats // 调用统计，远程服务为 nil
+
+	provider  string  // 提供者标识
+	version   Version // 提供者版本，versioned 为 false 时无版本
+	versioned bool
+	weight    int
+	draining  atomic.Bool // 下线中，不再分配新的调用
+
+	stream bool // 流式服务，只能通过 CallStream 调用
+}
+
+// versionString 返回提供者版本，无版本时为空
+func (e *serviceEntry) versionString() string {
+	if !e.versioned {
+		return ""
+	}
+	return e.version.String()
+}
+
+// service 同一服务名称下的所有提供者
+type service struct {
+	strategy  Strategy
+	providers []*serviceEntry // 写时复制，读取时可在锁外使用
+}
+
+// newEntry 将服务函数包装为擦除类型的服务条目，并记录请求与响应类型
+func newEntry[Req any, Resp any](fn ServiceFuncContext[Req, Resp]) *serviceEntry {
+	return &serviceEntry{
+		invoke: func(ctx context.Context, req any) (any, error) {
+			typedReq, _ := req.(Req)
+			return fn(ctx, typedReq)
+		},
+		reqType:  reflect.TypeFor[Req](),
+		respType: reflect.TypeFor[Resp](),
+		stats:    &serviceStats{},
+	}
 }
 
 type ServiceHub struct {
-	services map[string]serviceEntry
-	mu       sync.RWMutex
+	services     map[string]*service // 按不含版本的服务名称索引
+	interceptors []Interceptor       // 全局拦截器
+	peers        []*peer             // 远程节点，本地不存在的服务按添加顺序在远程节点中查找
+	policies     map[string]*policyState
+	registering  string // RegisteringFor 设置的组件名称
+	mu           sync.RWMutex
 }
 
 // NewServiceHub 创建一个新的 ServiceHub 实例
 func NewServiceHub() *ServiceHub {
 	return &ServiceHub{
-		services: make(map[string]serviceEntry),
+		services: make(map[string]*service),
+		policies: make(map[string]*policyState),
 	}
 }
 
-// Register 注册一个服务，支持覆盖和描述
+// Register 注册一个服务，支持覆盖和描述。服务函数不接收 context，
+// 调用方的截止时间仍由 ServiceHub 强制执行。
+// name 可以带版本，如 "user.get@v2"；同一名称与版本下使用 WithProvider 注册多个提供者
 func Register[Req any, Resp any](h *ServiceHub, name string, fn ServiceFunc[Req, Resp], opts ...RegisterOption) error {
+	return h.register(name, newEntry(func(ctx context.Context, req Req) (Resp, error) {
+		return fn(req)
+	}), opts...)
+}
+
+// RegisterContext 注册一个支持 context 的服务
+func RegisterContext[Req any, Resp any](h *ServiceHub, name string, fn ServiceFuncContext[Req, Resp], opts ...RegisterOption) error {
+	return h.register(name, newEntry(fn), opts...)
+}
+
+func (h *ServiceHub) register(name string, entry *serviceEntry, opts ...RegisterOption) error {
 	lib.Log.Debugf("\033[33m[ServiceHub] register service %s\033[0m", name)
-	h.mu.Lock()
-	defer h.mu.Unlock()
 	var opt registerOptions
 	for _, o := range opts {
 		o(&opt)
 	}
-	if _, exists := h.services[name]; exists {
-		if !opt.allowOverride {
-			lib.Log.Errorf("[ServiceHub] service '%s' already registered, duplicate registration is not allowed", name)
-			return &ErrServiceExists{name}
+	base, version := splitServiceName(name)
+	if version != "" {
+		v, err := ParseVersion(version)
+		if err != nil {
+			return fmt.Errorf("register service '%s' failed: %w", name, err)
 		}
-		// 允许覆盖时，打印警告
-		lib.Log.Warnf("[ServiceHub] service '%s' is being overridden", name)
+		entry.version, entry.versioned = v, true
+	}
+	entry.description = opt.description
+	entry.timeout = opt.timeout
+	entry.interceptors = opt.interceptors
+	entry.component = opt.component
+	entry.provider = opt.provider
+	entry.weight = opt.weight
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if entry.component == "" {
+		entry.component = h.registering
 	}
-	h.services[name] = serviceEntry{fn: fn, description: opt.description}
+	svc, exists := h.services[base]
+	if !exists {
+		svc = &service{strategy: RoundRobin()}
+		h.services[base] = svc
+	}
+	if opt.strategy != nil {
+		svc.strategy = opt.strategy
+	}
+	if opt.policy != nil {
+		h.policies[base] = newPolicyState(base, *opt.policy)
+	}
+	providers := make([]*serviceEntry, 0, len(svc.providers)+1)
+	for _, existing := range svc.providers {
+		if existing.provider == entry.provider && existing.versionString() == entry.versionString() {
+			if !opt.allowOverride {
+				lib.Log.Errorf("[ServiceHub] service '%s' already registered, duplicate registration is not allowed", name)
+				return &ErrServiceExists{name}
+			}
+			// 允许覆盖时，打印警告
+			lib.Log.Warnf("[ServiceHub] service '%s' is being overridden", name)
+			continue
+		}
+		providers = append(providers, existing)
+	}
+	svc.providers = append(providers, entry)
 	return nil
 }
 
 type registerOptions struct {
 	allowOverride bool
 	description   string
+	timeout       time.Duration
+	interceptors  []Interceptor
+	component     string
+	provider      string
+	weight        int
+	strategy      Strategy
+	policy        *Policy
 }
 type RegisterOption func(*registerOptions)
 
@@ -102,33 +231,241 @@ func WithDescription(desc string) RegisterOption {
 	return func(o *registerOptions) { o.description = desc }
 }
 
+// WithTimeout 设置服务单次调用的超时时间，与调用方 ctx 的截止时间取较早者
+func WithTimeout(timeout time.Duration) RegisterOption {
+	return func(o *registerOptions) { o.timeout = timeout }
+}
+
 // Call 调用一个已注册的服务，返回详细错误（带彩色日志）
 func Call[Req any, Resp any](h *ServiceHub, name string, req Req) (Resp, error) {
+	return CallContext[Req, Resp](context.Background(), h, name, req)
+}
+
+// CallContext 携带 context 调用一个已注册的服务。ctx 结束或超过服务超时时间时立即返回 ErrServiceTimeout，
+// 即使服务函数没有响应 ctx；ctx 中的调用方身份与追踪 ID 会传递给服务函数
+func CallContext[Req any, Resp any](ctx context.Context, h *ServiceHub, name string, req Req) (Resp, error) {
+	resp, err := h.call(ctx, name, reflect.TypeFor[Req](), reflect.TypeFor[Resp](), req)
+	typedResp, _ := resp.(Resp)
+	return typedResp, err
+}
+
+// call 按名称查找服务并校验类型，本地不存在时查找远程节点
+func (h *ServiceHub) call(ctx context.Context, name string, reqType, respType reflect.Type, req any) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	// 蓝色: \033[34m，重置: \033[0m
-	lib.Log.Debugf("\033[33m[ServiceHub] call service %s\033[0m", name)
+	lib.Log.Debugf("\033[33m[ServiceHub] call service %s%s\033[0m", name, traceSuffix(ctx))
+	base, _ := splitServiceName(name)
 	h.mu.RLock()
-	entry, exists := h.services[name]
+	policy := h.policies[base]
 	h.mu.RUnlock()
-	if !exists {
-		var zero Resp
-		// 红色: \033[31m
-		lib.Log.Error("service not found!!", "name", name)
-		return zero, &ErrServiceNotFound{name}
+	if policy == nil {
+		return h.callOnce(ctx, name, reqType, respType, req)
+	}
+	return policy.execute(ctx, name, req, func(ctx context.Context) (any, error) {
+		return h.callOnce(ctx, name, reqType, respType, req)
+	})
+}
+
+// callOnce 选择本地或远程提供者并调用一次
+func (h *ServiceHub) callOnce(ctx context.Context, name string, reqType, respType reflect.Type, req any) (any, error) {
+	entry, err := h.resolve(ctx, name, func(e *serviceEntry) bool {
+		return !e.stream && e.reqType == reqType && e.respType == respType
+	})
+	var notFound *ErrServiceNotFound
+	if errors.As(err, &notFound) {
+		entry, err = h.remoteEntry(ctx, name, reqType, respType)
+	}
+	if err != nil {
+		if errors.As(err, &notFound) {
+			// 红色: \033[31m
+			lib.Log.Error("service not found!!", "name", name)
+		} else {
+			lib.Log.Error("mservice type mismatch!!", "name", name)
+		}
+		return nil, err
+	}
+	return h.invokeEntry(ctx, name, entry, req)
+}
+
+// candidates 返回名称中版本范围内、未下线且类型匹配的最高版本的本地提供者。
+// 不带版本的名称匹配全部提供者，带版本时只匹配有版本的提供者
+func (h *ServiceHub) candidates(name string, matchType func(*serviceEntry) bool) ([]*serviceEntry, Strategy, error) {
+	base, version := splitServiceName(name)
+	versionRange, err := ParseVersionRange(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.mu.RLock()
+	svc, exists := h.services[base]
+	var providers []*serviceEntry
+	var strategy Strategy
+	if exists {
+		providers, strategy = svc.providers, svc.strategy
+	}
+	h.mu.RUnlock()
+
+	var matched []*serviceEntry
+	typeMismatch := false
+	for _, p := range providers {
+		if p.draining.Load() || (version != "" && (!p.versioned || !versionRange.Contains(p.version))) {
+			continue
+		}
+		if matchType != nil && !matchType(p) {
+			typeMismatch = true
+			continue
+		}
+		if len(matched) > 0 {
+			if c := compareEntryVersion(p, matched[0]); c < 0 {
+				continue
+			} else if c > 0 {
+				matched = matched[:0]
+			}
+		}
+		matched = append(matched, p)
+	}
+	if len(matched) == 0 {
+		if typeMismatch {
+			return nil, nil, &ErrServiceType{name}
+		}
+		return nil, nil, &ErrServiceNotFound{name}
+	}
+	return matched, strategy, nil
+}
+
+// compareEntryVersion 比较提供者版本，无版本的提供者最低
+func compareEntryVersion(a, b *serviceEntry) int {
+	switch {
+	case a.versioned && b.versioned:
+		return a.version.Compare(b.version)
+	case a.versioned:
+		return 1
+	case b.versioned:
+		return -1
+	}
+	return 0
+}
+
+// resolve 选择本地提供者，多个提供者时由服务的负载均衡策略选择
+func (h *ServiceHub) resolve(ctx context.Context, name string, matchType func(*serviceEntry) bool) (*serviceEntry, error) {
+	matched, strategy, err := h.candidates(name, matchType)
+	if err != nil {
+		return nil, err
+	}
+	if len(matched) == 1 || strategy == nil {
+		return matched[0], nil
+	}
+	views := make([]Provider, len(matched))
+	for i, p := range matched {
+		views[i] = p.view()
+	}
+	index := strategy.Select(ctx, views)
+	if index < 0 || index >= len(matched) {
+		index = 0
+	}
+	return matched[index], nil
+}
+
+// view 返回提供者的只读视图
+func (e *serviceEntry) view() Provider {
+	view := Provider{ID: e.provider, Version: e.versionString(), Weight: e.weight}
+	if e.stats != nil {
+		view.InFlight = e.stats.inFlight.Load()
 	}
-	typedFn, ok := entry.fn.(ServiceFunc[Req, Resp])
-	if !ok {
-		var zero Resp
-		lib.Log.Error("mservice type mismatch!!", "name", name)
-		return zero, &ErrServiceType{name}
+	return view
+}
+
+// invokeEntry 经过全局与服务级拦截器调用服务
+func (h *ServiceHub) invokeEntry(ctx context.Context, name string, entry *serviceEntry, req any) (resp any, err error) {
+	h.mu.RLock()
+	interceptors := h.interceptors
+	h.mu.RUnlock()
+
+	info := &CallInfo{Service: name, Start: time.Now()}
+	invoke := func(ctx context.Context, req any) (any, error) {
+		return invokeWithDeadline(ctx, name, entry, req)
+	}
+	if len(interceptors) > 0 || len(entry.interceptors) > 0 {
+		all := make([]Interceptor, 0, len(interceptors)+len(entry.interceptors))
+		all = append(append(all, interceptors...), entry.interceptors...)
+		invoke = chainInterceptors(all, info, invoke)
+	}
+	if entry.stats == nil {
+		return invoke(ctx, req)
+	}
+	entry.stats.begin()
+	defer func() {
+		if r := recover(); r != nil {
+			entry.stats.end(fmt.Errorf("service '%s' panic: %v", name, r))
+			panic(r)
+		}
+		entry.stats.end(err)
+	}()
+	return invoke(ctx, req)
+}
+
+// invokeWithDeadline 执行服务函数，ctx 结束时不再等待服务函数返回。
+// 服务函数中的 panic 会在调用方 goroutine 中以 *ErrServicePanic 重新抛出
+func invokeWithDeadline(ctx context.Context, name string, entry *serviceEntry, req any) (any, error) {
+	if entry.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, entry.timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &ErrServiceTimeout{Name: name, Err: err}
+	}
+	if ctx.Done() == nil {
+		res := invokeRecovered(ctx, name, entry, req)
+		if res.panicked != nil {
+			panic(res.panicked)
+		}
+		return res.resp, res.err
+	}
+
+	done := make(chan invokeResult, 1)
+	go func() {
+		done <- invokeRecovered(ctx, name, entry, req)
+	}()
+
+	select {
+	case res := <-done:
+		if res.panicked != nil {
+			panic(res.panicked)
+		}
+		return res.resp, res.err
+	case <-ctx.Done():
+		lib.Log.Warnf("[ServiceHub] call service %s aborted: %v%s", name, ctx.Err(), traceSuffix(ctx))
+		return nil, &ErrServiceTimeout{Name: name, Err: ctx.Err()}
 	}
-	return typedFn(req)
 }
 
-// Unregister 注销一个服务
+// invokeResult 服务函数的执行结果，panicked 不为空时表示服务函数 panic
+type invokeResult struct {
+	resp     any
+	err      error
+	panicked *ErrServicePanic
+}
+
+// invokeRecovered 执行服务函数，并将 panic 转换为 *ErrServicePanic
+func invokeRecovered(ctx context.Context, name string, entry *serviceEntry, req any) (res invokeResult) {
+	completed := false
+	defer func() {
+		if !completed {
+			res.panicked = &ErrServicePanic{Name: name, Value: recover(), Stack: debug.Stack()}
+		}
+	}()
+	res.resp, res.err = entry.invoke(ctx, req)
+	completed = true
+	return res
+}
+
+// Unregister 注销一个服务，名称不带版本时注销所有版本，带版本时只注销该版本
 func (h *ServiceHub) Unregister(name string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	delete(h.services, name)
+	h.removeProviders(name, func(*serviceEntry) bool { return true })
 }
 
 // UnregisterMany 批量注销服务
@@ -136,38 +473,142 @@ func (h *ServiceHub) UnregisterMany(names ...string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	for _, name := range names {
-		delete(h.services, name)
+		h.removeProviders(name, func(*serviceEntry) bool { return true })
 	}
 }
 
-// Has 检查服务是否存在
-func (h *ServiceHub) Has(name string) bool {
+// UnregisterProvider 注销服务的指定提供者，不等待进行中的调用
+func (h *ServiceHub) UnregisterProvider(name, provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeProviders(name, func(e *serviceEntry) bool { return e.provider == provider })
+}
+
+// DrainProvider 将服务的指定提供者标记为下线，不再分配新的调用，
+// 等待进行中的调用完成后注销该提供者；ctx 结束时返回错误，提供者保持下线状态
+func (h *ServiceHub) DrainProvider(ctx context.Context, name, provider string) error {
+	match := func(e *serviceEntry) bool { return e.provider == provider }
+	entries := h.providers(name, match)
+	if len(entries) == 0 {
+		return &ErrServiceNotFound{name}
+	}
+	for _, e := range entries {
+		e.draining.Store(true)
+	}
+	lib.Log.Infof("[ServiceHub] draining provider '%s' of service '%s'", provider, name)
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		inFlight := int64(0)
+		for _, e := range entries {
+			inFlight += e.stats.inFlight.Load()
+		}
+		if inFlight == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("drain provider '%s' of service '%s': %w", provider, name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeProviders(name, func(e *serviceEntry) bool {
+		for _, drained := range entries {
+			if e == drained {
+				return true
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// providers 返回名称对应的提供者，带版本时只返回该版本
+func (h *ServiceHub) providers(name string, match func(*serviceEntry) bool) []*serviceEntry {
+	base, version := splitServiceName(name)
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	_, exists := h.services[name]
-	return exists
+	svc, exists := h.services[base]
+	if !exists {
+		return nil
+	}
+	var res []*serviceEntry
+	for _, e := range svc.providers {
+		if sameVersion(e, version) && match(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// removeProviders 移除名称对应且满足 match 的提供者，调用方持有写锁
+func (h *ServiceHub) removeProviders(name string, match func(*serviceEntry) bool) {
+	base, version := splitServiceName(name)
+	svc, exists := h.services[base]
+	if !exists {
+		return
+	}
+	providers := make([]*serviceEntry, 0, len(svc.providers))
+	for _, e := range svc.providers {
+		if !(sameVersion(e, version) && match(e)) {
+			providers = append(providers, e)
+		}
+	}
+	if len(providers) == 0 {
+		delete(h.services, base)
+		return
+	}
+	svc.providers = providers
+}
+
+// sameVersion 判断提供者是否为指定版本，version 为空时匹配所有版本
+func sameVersion(e *serviceEntry, version string) bool {
+	if version == "" {
+		return true
+	}
+	v, err := ParseVersion(version)
+	return err == nil && e.versioned && e.version.Compare(v) == 0
+}
+
+// Has 检查服务是否存在，名称可以带版本范围
+func (h *ServiceHub) Has(name string) bool {
+	_, _, err := h.candidates(name, nil)
+	return err == nil
 }
 
-// ListServices 获取所有已注册服务名
+// ListServices 获取所有已注册服务名，有版本的服务名形如 "user.get@2.0.0"
 func (h *ServiceHub) ListServices() []string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	names := make([]string, 0, len(h.services))
-	for name := range h.services {
-		names = append(names, name)
+	for base, svc := range h.services {
+		seen := make(map[string]bool)
+		for _, e := range svc.providers {
+			name := base
+			if e.versioned {
+				name += "@" + e.versionString()
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
 	}
+	sort.Strings(names)
 	return names
 }
 
-// GetServiceDescription 获取服务描述
+// GetServiceDescription 获取服务描述，名称可以带版本范围
 func (h *ServiceHub) GetServiceDescription(name string) (string, bool) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	entry, exists := h.services[name]
-	if !exists {
+	matched, _, err := h.candidates(name, nil)
+	if err != nil {
 		return "", false
 	}
-	return entry.description, true
+	return matched[0].description, true
 }
 
 // 错误类型
@@ -183,12 +624,45 @@ type ErrServiceType struct{ Name string }
 
 func (e *ErrServiceType) Error() string { return "service '" + e.Name + "' type mismatch" }
 
+// ErrServiceTimeout 调用方 ctx 结束或超过服务超时时间，Err 为 context.DeadlineExceeded 或 context.Canceled
+type ErrServiceTimeout struct {
+	Name string
+	Err  error
+}
+
+func (e *ErrServiceTimeout) Error() string {
+	return "service '" + e.Name + "' call aborted: " + e.Err.Error()
+}
+
+func (e *ErrServiceTimeout) Unwrap() error { return e.Err }
+
+// ErrServicePanic 服务函数 panic，Stack 为 panic 时的堆栈
+type ErrServicePanic struct {
+	Name  string
+	Value any
+	Stack []byte
+}
+
+func (e *ErrServicePanic) Error() string {
+	return fmt.Sprintf("service '%s' panic: %v", e.Name, e.Value)
+}
+
 // RegisterToService 注册一个服务服到 HUB 单例
 func RegisterToService[Req any, Resp any](name string, fn ServiceFunc[Req, Resp], opts ...RegisterOption) error {
 	return Register(GetHubInstance(), name, fn, opts...)
 }
 
+// RegisterToServiceContext 注册一个支持 context 的服务到 HUB 单例
+func RegisterToServiceContext[Req any, Resp any](name string, fn ServiceFuncContext[Req, Resp], opts ...RegisterOption) error {
+	return RegisterContext(GetHubInstance(), name, fn, opts...)
+}
+
 // CallFromService 通过单例调用一个服务
 func CallFromService[Req any, Resp any](name string, req Req) (Resp, error) {
 	return Call[Req, Resp](GetHubInstance(), name, req)
 }
+
+// CallFromServiceContext 通过单例携带 context 调用一个服务
+func CallFromServiceContext[Req any, Resp any](ctx context.Context, name string, req Req) (Resp, error) {
+	return CallContext[Req, Resp](ctx, GetHubInstance(), name, req)
+}
@@ -0,0 +1,171 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type echoReq struct{ Text string }
+type echoResp struct {
+	Text   string
+	UserId int64
+	Trace  string
+}
+
+func TestCallContext_PropagatesCaller(t *testing.T) {
+	h := NewServiceHub()
+	err := RegisterContext(h, "echo", func(ctx context.Context, req *echoReq) (*echoResp, error) {
+		caller, _ := CallerFrom(ctx)
+		return &echoResp{Text: req.Text, UserId: caller.UserId, Trace: TraceIDFrom(ctx)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithTraceID(WithCaller(context.Background(), Caller{UserId: 7, Username: "bob"}), "t-1")
+	resp, err := CallContext[*echoReq, *echoResp](ctx, h, "echo", &echoReq{Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "hi" || resp.UserId != 7 || resp.Trace != "t-1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestCallContext_EnforcesDeadline(t *testing.T) {
+	h := NewServiceHub()
+	release := make(chan struct{})
+	defer close(release)
+	// 服务函数不接收 context，调用方仍应在截止时间后返回
+	Register(h, "slow", func(req int) (int, error) {
+		<-release
+		return req, nil
+	})
+	RegisterContext(h, "limited", func(ctx context.Context, req int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := CallContext[int, int](ctx, h, "slow", 1)
+	var timeout *ErrServiceTimeout
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("call was not aborted at the deadline")
+	}
+
+	if _, err := Call[int, int](h, "limited", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected service timeout, got %v", err)
+	}
+}
+
+func TestCall_LegacyRegistration(t *testing.T) {
+	h := NewServiceHub()
+	Register(h, "double", func(req int) (int, error) { return req * 2, nil })
+
+	if resp, err := CallContext[int, int](context.Background(), h, "double", 21); err != nil || resp != 42 {
+		t.Errorf("unexpected result: %d %v", resp, err)
+	}
+	var typeErr *ErrServiceType
+	if _, err := Call[string, int](h, "double", "x"); !errors.As(err, &typeErr) {
+		t.Errorf("expected type mismatch, got %v", err)
+	}
+	var notFound *ErrServiceNotFound
+	if _, err := Call[int, int](h, "missing", 1); !errors.As(err, &notFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestCallContext_RepanicsInCaller(t *testing.T) {
+	h := NewServiceHub()
+	Register(h, "boom", func(req int) (int, error) { panic("boom") })
+
+	defer func() {
+		r, ok := recover().(*ErrServicePanic)
+		if !ok || r.Value != "boom" || len(r.Stack) == 0 {
+			t.Errorf("expected panic to be re-raised, got %v", r)
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	CallContext[int, int](ctx, h, "boom", 1)
+}
+
+func TestCallContext_RepanicsWithoutDeadline(t *testing.T) {
+	h := NewServiceHub()
+	Register(h, "boom", func(req int) (int, error) { panic("boom") })
+
+	defer func() {
+		r, ok := recover().(*ErrServicePanic)
+		if !ok || r.Value != "boom" || len(r.Stack) == 0 {
+			t.Errorf("expected *ErrServicePanic without a deadline, got %v", r)
+		}
+	}()
+	CallContext[int, int](context.Background(), h, "boom", 1)
+}
+
+type createReq struct {
+	Name string `validate:"required"`
+}
+
+func TestInterceptors_Order(t *testing.T) {
+	h := NewServiceHub()
+	var trace []string
+	record := func(tag string) Interceptor {
+		return func(ctx context.Context, info *CallInfo, req any, next Invoker) (any, error) {
+			trace = append(trace, tag+">"+info.Service)
+			resp, err := next(ctx, req)
+			trace = append(trace, tag+"<")
+			return resp, err
+		}
+	}
+	h.Use(record("global"), Logging(time.Second))
+	Register(h, "create", func(req *createReq) (string, error) {
+		trace = append(trace, "service")
+		return req.Name, nil
+	}, WithInterceptors(Validation(), record("local")))
+
+	if resp, err := Call[*createReq, string](h, "create", &createReq{Name: "a"}); err != nil || resp != "a" {
+		t.Fatalf("unexpected result: %q %v", resp, err)
+	}
+	expected := []string{"global>create", "local>create", "service", "local<", "global<"}
+	if len(trace) != len(expected) {
+		t.Fatalf("unexpected trace: %v", trace)
+	}
+	for i := range expected {
+		if trace[i] != expected[i] {
+			t.Fatalf("unexpected trace: %v", trace)
+		}
+	}
+
+	trace = nil
+	var validationErr *ErrServiceValidation
+	if _, err := Call[*createReq, string](h, "create", &createReq{}); !errors.As(err, &validationErr) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	if len(trace) != 2 {
+		t.Errorf("service should not run after failed validation: %v", trace)
+	}
+}
+
+func TestInterceptors_Recovery(t *testing.T) {
+	h := NewServiceHub()
+	h.Use(Recovery())
+	Register(h, "boom", func(req int) (int, error) { panic("boom") })
+
+	var panicErr *ErrServicePanic
+	if _, err := Call[int, int](h, "boom", 1); !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("expected recovered panic, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := CallContext[int, int](ctx, h, "boom", 1); !errors.As(err, &panicErr) || len(panicErr.Stack) == 0 {
+		t.Errorf("expected recovered panic with stack, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package servicehub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"reflect"
+	"sync"
+
+	"github.com/gloopai/gloop/lib"
+)
+
+/**
+
+// 流式服务：逐条发送响应，send 返回错误时（调用方取消或超时）应尽快返回
+servicehub.RegisterStreamToService("order.export", func(ctx context.Context, req *ExportReq, send func(*OrderRow) error) error {
+	for rows.Next() {
+		if err := send(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+})
+
+// 逐条消费，提前退出循环即取消服务
+stream, err := servicehub.CallStreamFromService[*ExportReq, *OrderRow](ctx, "order.export", req)
+if err != nil {
+	return err
+}
+for row, err := range stream.All() {
+	if err != nil {
+		return err
+	}
+	w.Write(row)
+}
+
+*/
+
+// StreamFunc 流式服务函数，通过 send 逐条发送响应，返回后流结束。
+// 调用方取消或超时后 send 返回错误，服务函数应尽快返回
+type StreamFunc[Req any, Resp any] func(ctx context.Context, req Req, send func(Resp) error) error
+
+type streamSendKey struct{}
+
+// streamSender 发送一条响应，ctx 为服务函数收到的 ctx
+type streamSender func(ctx context.Context, resp any) error
+
+// RegisterStream 注册一个流式服务，只能通过 CallStream 调用，不支持远程调用。
+// 拦截器与调用统计作用于整个流，超时时间限制整个流的持续时间
+func RegisterStream[Req any, Resp any](h *ServiceHub, name string, fn StreamFunc[Req, Resp], opts ...RegisterOption) error {
+	entry := &serviceEntry{
+		invoke: func(ctx context.Context, req any) (any, error) {
+			send, _ := ctx.Value(streamSendKey{}).(streamSender)
+			if send == nil {
+				return nil, &ErrServiceType{name}
+			}
+			typedReq, _ := req.(Req)
+			return nil, fn(ctx, typedReq, func(resp Resp) error { return send(ctx, resp) })
+		},
+		reqType:  reflect.TypeFor[Req](),
+		respType: reflect.TypeFor[Resp](),
+		stats:    &serviceStats{},
+		stream:   true,
+	}
+	return h.register(name, entry, opts...)
+}
+
+// Stream 流式调用的响应，Recv 与 All 不能并发使用
+type Stream[Resp any] struct {
+	items  chan Resp
+	done   chan struct{} // 服务函数返回后关闭
+	err    error         // done 关闭后可读
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+// CallStream 调用流式服务，返回后服务函数开始执行，直到流结束、ctx 结束或 Close。
+// 服务函数的错误与 panic 在流结束时由 Recv 返回
+func CallStream[Req any, Resp any](ctx context.Context, h *ServiceHub, name string, req Req) (*Stream[Resp], error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	lib.Log.Debugf("\033[33m[ServiceHub] call stream %s%s\033[0m", name, traceSuffix(ctx))
+	reqType, respType := reflect.TypeFor[Req](), reflect.TypeFor[Resp]()
+	entry, err := h.resolve(ctx, name, func(e *serviceEntry) bool {
+		return e.stream && e.reqType == reqType && e.respType == respType
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Stream[Resp]{items: make(chan Resp), done: make(chan struct{}), cancel: cancel}
+	var send streamSender = func(ctx context.Context, resp any) error {
+		typedResp, _ := resp.(Resp)
+		select {
+		case s.items <- typedResp:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	ctx = context.WithValue(ctx, streamSendKey{}, send)
+
+	go func() {
+		defer close(s.done)
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				s.err = asPanicError(name, r)
+			}
+		}()
+		_, s.err = h.invokeEntry(ctx, name, entry, req)
+	}()
+	return s, nil
+}
+
+// Recv 接收下一条响应，流正常结束时返回 io.EOF
+func (s *Stream[Resp]) Recv() (Resp, error) {
+	var zero Resp
+	select {
+	case resp := <-s.items:
+		return resp, nil
+	case <-s.done:
+		if s.err != nil {
+			return zero, s.err
+		}
+		return zero, io.EOF
+	}
+}
+
+// All 返回逐条读取响应的迭代器，流以错误结束时最后一项带有该错误，提前退出循环时关闭流
+func (s *Stream[Resp]) All() iter.Seq2[Resp, error] {
+	return func(yield func(Resp, error) bool) {
+		for {
+			resp, err := s.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(resp, err) || err != nil {
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// Close 取消流并等待服务调用返回，可以重复调用
+func (s *Stream[Resp]) Close() {
+	s.once.Do(s.cancel)
+	<-s.done
+}
+
+// RegisterStreamToService 注册一个流式服务到 HUB 单例
+func RegisterStreamToService[Req any, Resp any](name string, fn StreamFunc[Req, Resp], opts ...RegisterOption) error {
+	return RegisterStream(GetHubInstance(), name, fn, opts...)
+}
+
+// CallStreamFromService 通过单例调用一个流式服务
+func CallStreamFromService[Req any, Resp any](ctx context.Context, name string, req Req) (*Stream[Resp], error) {
+	return CallStream[Req, Resp](ctx, GetHubInstance(), name, req)
+}
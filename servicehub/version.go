@@ -0,0 +1,282 @@
+package servicehub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/**
+
+// 服务名称中 @ 之后为版本，注册时为具体版本，调用时为版本范围
+servicehub.RegisterToService("user.get@v1.4.0", getUserV1)
+servicehub.RegisterToService("user.get@v2", getUserV2) // 等同于 2.0.0
+
+servicehub.CallFromService[*UserReq, *UserV2]("user.get@^2", req)     // 2.x 中的最高版本
+servicehub.CallFromService[*UserReq, *User]("user.get@~1.4", req)     // >=1.4.0 <1.5.0
+servicehub.CallFromService[*UserReq, *User]("user.get@>=1.0 <2", req) // 1.x 中的最高版本
+servicehub.CallFromService[*UserReq, *UserV2]("user.get", req)        // 类型匹配的最高版本
+
+*/
+
+// Version 语义化版本，如 1.2.3、v2、2.1.0-beta.1
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string // 预发布标识，如 beta.1
+}
+
+// ParseVersion 解析版本，允许 v 前缀与省略次版本号、修订号
+func ParseVersion(s string) (Version, error) {
+	nums, pre, wildcard, err := parsePartial(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if wildcard || len(nums) == 0 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	return versionOf(nums, pre), nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare 按语义化版本的优先级比较，返回 -1、0、1
+func (v Version) Compare(o Version) int {
+	for _, d := range [3]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(o.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePreIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePreIdentifier 数字标识按数值比较且低于非数字标识
+func comparePreIdentifier(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(x - y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// VersionRange 版本范围，语法与 npm semver 相同：
+// 空格分隔的条件同时满足，|| 分隔的条件组满足其一；
+// 支持 =、>、>=、<、<=、^、~、x 通配与省略的版本号，如 "^1.2"、"~2.0.1"、">=1 <3"、"2.x"、"v2"
+type VersionRange struct {
+	raw  string
+	sets [][]comparator
+}
+
+type comparator struct {
+	op string
+	v  Version
+}
+
+// ParseVersionRange 解析版本范围，空字符串与 * 表示任意版本
+func ParseVersionRange(s string) (VersionRange, error) {
+	r := VersionRange{raw: s}
+	for _, part := range strings.Split(s, "||") {
+		var set []comparator
+		for _, token := range strings.Fields(part) {
+			comparators, err := parseComparator(token)
+			if err != nil {
+				return VersionRange{}, fmt.Errorf("invalid version range %q: %w", s, err)
+			}
+			set = append(set, comparators...)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+func (r VersionRange) String() string { return r.raw }
+
+// Contains 判断版本是否在范围内
+func (r VersionRange) Contains(v Version) bool {
+	for _, set := range r.sets {
+		matched := true
+		for _, c := range set {
+			if !c.matches(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v Version) bool {
+	d := v.Compare(c.v)
+	switch c.op {
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	default:
+		return d == 0
+	}
+}
+
+// parseComparator 将单个条件展开为基本比较条件
+func parseComparator(token string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(token, prefix) {
+			op, token = prefix, token[len(prefix):]
+			break
+		}
+	}
+	nums, pre, _, err := parsePartial(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(nums) == 0 {
+		// *、x 或空：任意版本
+		if op == "<" || op == ">" {
+			return []comparator{{op: "<", v: Version{Pre: "0"}}}, nil
+		}
+		return nil, nil
+	}
+	lower := versionOf(nums, pre)
+	full := len(nums) == 3
+
+	switch op {
+	case "^":
+		upper := bump(nums, caretIndex(nums))
+		return []comparator{{">=", lower}, {"<", upper}}, nil
+	case "~":
+		index := 1
+		if len(nums) == 1 {
+			index = 0
+		}
+		return []comparator{{">=", lower}, {"<", bump(nums, index)}}, nil
+	case ">=":
+		return []comparator{{">=", lower}}, nil
+	case ">":
+		if full {
+			return []comparator{{">", lower}}, nil
+		}
+		return []comparator{{">=", bump(nums, len(nums)-1)}}, nil
+	case "<":
+		if full {
+			return []comparator{{"<", lower}}, nil
+		}
+		return []comparator{{"<", Version{Major: lower.Major, Minor: lower.Minor, Pre: "0"}}}, nil
+	case "<=":
+		if full {
+			return []comparator{{"<=", lower}}, nil
+		}
+		return []comparator{{"<", bump(nums, len(nums)-1)}}, nil
+	default:
+		if full {
+			return []comparator{{"=", lower}}, nil
+		}
+		return []comparator{{">=", lower}, {"<", bump(nums, len(nums)-1)}}, nil
+	}
+}
+
+// caretIndex ^ 允许变化的最高位：第一个非零位，全为零时为最后一位
+func caretIndex(nums []int) int {
+	for i, n := range nums {
+		if n != 0 {
+			return i
+		}
+	}
+	if len(nums) < 3 {
+		return len(nums) - 1
+	}
+	return 2
+}
+
+// bump 将第 index 位加一并清零低位，返回该版本的最小预发布版本，作为不包含的上界
+func bump(nums []int, index int) Version {
+	parts := [3]int{}
+	copy(parts[:], nums)
+	parts[index]++
+	for i := index + 1; i < 3; i++ {
+		parts[i] = 0
+	}
+	return Version{Major: parts[0], Minor: parts[1], Patch: parts[2], Pre: "0"}
+}
+
+func versionOf(nums []int, pre string) Version {
+	parts := [3]int{}
+	copy(parts[:], nums)
+	return Version{Major: parts[0], Minor: parts[1], Patch: parts[2], Pre: pre}
+}
+
+// parsePartial 解析可能省略或使用 x 通配的版本号，返回已给出的数字部分
+func parsePartial(s string) (nums []int, pre string, wildcard bool, err error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return nil, "", true, nil
+	}
+	s, pre, _ = strings.Cut(s, "-")
+	s, _, _ = strings.Cut(s, "+") // 忽略构建元数据
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, "", false, fmt.Errorf("invalid version %q", s)
+	}
+	for _, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			return nums, "", true, nil
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, "", false, fmt.Errorf("invalid version %q", s)
+		}
+		nums = append(nums, n)
+	}
+	if pre != "" && len(nums) < 3 {
+		return nil, "", false, fmt.Errorf("invalid version %q", s)
+	}
+	return nums, pre, false, nil
+}
+
+// splitServiceName 拆分服务名称与 @ 之后的版本或版本范围
+func splitServiceName(name string) (base, version string) {
+	base, version, _ = strings.Cut(name, "@")
+	return base, version
+}
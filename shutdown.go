@@ -0,0 +1,76 @@
+package gloop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules"
+)
+
+// defaultShutdownTimeout 未配置 ShutdownTimeout 时的停机时限
+const defaultShutdownTimeout = 30 * time.Second
+
+func (c *Container) shutdownTimeout() time.Duration {
+	config := c.config()
+	if config == nil || config.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return config.ShutdownTimeout
+}
+
+// doShutdown 在 ShutdownTimeout 内分阶段停机：
+// 停止组件监管与重启，停止接收流量并等待处理中的请求，等待事件处理器完成，
+// 按逆序停止组件（关闭数据库、停止节点心跳等），最后刷新日志
+func (c *Container) doShutdown() error {
+	timeout := c.shutdownTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lib.Log.Infof("[Container] shutting down, timeout %s", timeout)
+	c.ready.Store(false)
+	var errs []error
+	if c.supervisor != nil {
+		if err := c.supervisor.shutdown(ctx); err != nil {
+			lib.Log.Errorf("[Container] %v", err)
+			errs = append(errs, err)
+		}
+	}
+	if err := c.doDrainComponents(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Events != nil {
+		if err := c.Events.Drain(ctx); err != nil {
+			lib.Log.Errorf("[Container] %v", err)
+			errs = append(errs, err)
+		}
+	}
+	if err := c.doStopComponents(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if ctx.Err() != nil {
+		errs = append(errs, fmt.Errorf("shutdown did not complete within %s", timeout))
+	}
+	c.doStopAdmin(ctx)
+	lib.Log.Info("[Container] shutdown complete")
+	lib.Log.Flush()
+	return errors.Join(errs...)
+}
+
+// 按逆序让已初始化的组件停止接收流量并等待处理中的请求
+func (c *Container) doDrainComponents(ctx context.Context) error {
+	var errs []error
+	for i := c.initialized - 1; i >= 0; i-- {
+		drainer, ok := c.components[i].(modules.Drainer)
+		if !ok {
+			continue
+		}
+		if err := drainer.Drain(ctx); err != nil {
+			lib.Log.Errorf("[Container] drain component '%s' failed: %v", c.components[i].Name(), err)
+			errs = append(errs, fmt.Errorf("drain component '%s' failed: %w", c.components[i].Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,195 @@
+package gloop
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/lib"
+	"github.com/gloopai/gloop/modules"
+)
+
+// supervisor 跟踪组件状态，监管组件的 Runner 主循环并按重启策略重启
+type supervisor struct {
+	events  *events.EventBus
+	mu      sync.RWMutex
+	entries []*supervisedComponent
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type supervisedComponent struct {
+	comp     modules.ComponentV2
+	policy   modules.RestartPolicy
+	state    modules.ComponentState
+	restarts int
+	lastErr  error
+}
+
+// newSupervisor 为排序后的组件创建监管器，policies 按组件名称覆盖组件声明的重启策略
+func newSupervisor(eb *events.EventBus, components []modules.ComponentV2, policies map[string]modules.RestartPolicy) *supervisor {
+	s := &supervisor{events: eb}
+	for _, comp := range components {
+		entry := &supervisedComponent{comp: comp}
+		if supervised, ok := comp.(modules.Supervised); ok {
+			entry.policy = supervised.RestartPolicy()
+		}
+		if policy, ok := policies[comp.Name()]; ok {
+			entry.policy = policy
+		}
+		s.entries = append(s.entries, entry)
+	}
+	return s
+}
+
+// transition 更新组件状态并在事件总线上发布状态变化
+func (s *supervisor) transition(entry *supervisedComponent, to modules.ComponentState, err error) {
+	s.mu.Lock()
+	from := entry.state
+	entry.state = to
+	if err != nil {
+		entry.lastErr = err
+	}
+	change := modules.ComponentStateChange{
+		Component: entry.comp.Name(),
+		From:      from,
+		To:        to,
+		Err:       err,
+		Restarts:  entry.restarts,
+		Time:      time.Now(),
+	}
+	s.mu.Unlock()
+
+	lib.Log.Debugf("[Container] component '%s' %s -> %s", change.Component, from, to)
+	if s.events != nil {
+		s.events.Publish(modules.EventComponentStatePrefix+string(to), change)
+	}
+}
+
+// statuses 返回所有组件的状态快照
+func (s *supervisor) statuses() []modules.ComponentStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]modules.ComponentStatus, 0, len(s.entries))
+	for _, entry := range s.entries {
+		res = append(res, modules.ComponentStatus{
+			Component: entry.comp.Name(),
+			State:     entry.state,
+			Restarts:  entry.restarts,
+			LastError: entry.lastErr,
+		})
+	}
+	return res
+}
+
+// start 创建监管用的 context，此后 supervise 启动的主循环在 shutdown 时结束
+func (s *supervisor) start(ctx context.Context) context.Context {
+	ctx, s.cancel = context.WithCancel(ctx)
+	return ctx
+}
+
+// supervise 组件实现 Runner 时在独立 goroutine 中运行其主循环，
+// 失败或退出时按重启策略依次调用 Stop、Init、Start 重启组件
+func (s *supervisor) supervise(ctx context.Context, entry *supervisedComponent) {
+	runner, ok := entry.comp.(modules.Runner)
+	if !ok {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			err := runSafely(ctx, entry.comp.Name(), runner)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				lib.Log.Errorf("[Container] component '%s' failed: %v", entry.comp.Name(), err)
+				s.transition(entry, modules.StateFailed, err)
+			} else {
+				s.transition(entry, modules.StateStopped, nil)
+			}
+			if !entry.policy.ShouldRestart(err, s.restartCount(entry)) {
+				return
+			}
+			if !s.restart(ctx, entry) {
+				return
+			}
+		}
+	}()
+}
+
+// restart 按退避时间重启组件，直到启动成功、超过重启策略限制或 ctx 结束
+func (s *supervisor) restart(ctx context.Context, entry *supervisedComponent) bool {
+	for {
+		restarts := s.restartCount(entry)
+		backoff := entry.policy.Backoff(restarts)
+		lib.Log.Warnf("[Container] restarting component '%s' in %s (restarts: %d)", entry.comp.Name(), backoff, restarts)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+
+		s.mu.Lock()
+		entry.restarts++
+		s.mu.Unlock()
+		s.transition(entry, modules.StateInitializing, nil)
+
+		// Stop 会释放 Init 中创建的资源（如 v1 组件的 Close、Destroy），重新 Init 后再启动
+		if err := entry.comp.Stop(ctx); err != nil {
+			lib.Log.Errorf("[Container] stop component '%s' before restart failed: %v", entry.comp.Name(), err)
+		}
+		err := entry.comp.Init(ctx)
+		if err == nil {
+			err = entry.comp.Start(ctx)
+		}
+		if err == nil {
+			s.transition(entry, modules.StateRunning, nil)
+			return true
+		}
+		lib.Log.Errorf("[Container] restart component '%s' failed: %v", entry.comp.Name(), err)
+		s.transition(entry, modules.StateFailed, err)
+		if !entry.policy.ShouldRestart(err, s.restartCount(entry)) {
+			return false
+		}
+	}
+}
+
+func (s *supervisor) restartCount(entry *supervisedComponent) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return entry.restarts
+}
+
+// shutdown 停止所有主循环与重启，等待其退出或 ctx 结束
+func (s *supervisor) shutdown(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("component run loops did not exit: %w", ctx.Err())
+	}
+}
+
+// runSafely 运行组件主循环，并将 panic 连同堆栈记录到日志后转换为错误
+func runSafely(ctx context.Context, name string, runner modules.Runner) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("component '%s' panic: %v", name, r)
+			lib.Log.Errorf("%v\n%s", err, debug.Stack())
+		}
+	}()
+	return runner.Run(ctx)
+}
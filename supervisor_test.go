@@ -0,0 +1,148 @@
+package gloop
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gloopai/gloop/events"
+	"github.com/gloopai/gloop/modules"
+	"github.com/gloopai/gloop/servicehub"
+)
+
+type flakyComponent struct {
+	runs   int32
+	inits  int32
+	starts int32
+}
+
+func (f *flakyComponent) Name() string                    { return "flaky" }
+func (f *flakyComponent) Init(ctx context.Context) error  { atomic.AddInt32(&f.inits, 1); return nil }
+func (f *flakyComponent) Stop(ctx context.Context) error  { return nil }
+func (f *flakyComponent) Start(ctx context.Context) error { atomic.AddInt32(&f.starts, 1); return nil }
+
+func (f *flakyComponent) Run(ctx context.Context) error {
+	switch atomic.AddInt32(&f.runs, 1) {
+	case 1:
+		return errors.New("boom")
+	case 2:
+		panic("kaboom")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestSupervisor_RestartOnFailure(t *testing.T) {
+	eb := events.NewEventBus()
+	var mu sync.Mutex
+	var failures int
+	eb.Subscribe(modules.EventComponentStatePrefix+string(modules.StateFailed), func(msg *events.EventMessage) {
+		mu.Lock()
+		failures++
+		mu.Unlock()
+	})
+
+	comp := &flakyComponent{}
+	s := newSupervisor(eb, []modules.ComponentV2{comp}, map[string]modules.RestartPolicy{
+		"flaky": {Mode: modules.RestartOnFailure, InitialBackoff: time.Millisecond},
+	})
+	ctx := s.start(context.Background())
+	s.transition(s.entries[0], modules.StateRunning, nil)
+	s.supervise(ctx, s.entries[0])
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&comp.runs) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := s.shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	status := s.statuses()[0]
+	if status.Restarts != 2 || atomic.LoadInt32(&comp.starts) != 2 || atomic.LoadInt32(&comp.inits) != 2 {
+		t.Errorf("expected 2 restarts, got %+v (inits %d, starts %d)", status, comp.inits, comp.starts)
+	}
+	if status.State != modules.StateRunning || status.LastError == nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if err := eb.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if failures != 2 {
+		t.Errorf("expected 2 failed events, got %d", failures)
+	}
+}
+
+func TestRestartPolicy_Backoff(t *testing.T) {
+	p := modules.RestartPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	if p.Backoff(0) != time.Second || p.Backoff(2) != 4*time.Second || p.Backoff(10) != 5*time.Second {
+		t.Errorf("unexpected backoff: %v %v %v", p.Backoff(0), p.Backoff(2), p.Backoff(10))
+	}
+	never := modules.RestartPolicy{}
+	if never.ShouldRestart(errors.New("x"), 0) {
+		t.Error("RestartNever should not restart")
+	}
+}
+
+// brokenRegistrar 注册服务总是失败的组件
+type brokenRegistrar struct{ flakyComponent }
+
+func (b *brokenRegistrar) Name() string { return "broken" }
+func (b *brokenRegistrar) RegisterService(ctx context.Context) error {
+	return errors.New("duplicate service")
+}
+
+func TestContainer_RegisterServiceFailureMarksComponentFailed(t *testing.T) {
+	comp := &brokenRegistrar{}
+	c := &Container{}
+	c.AddV2(comp)
+	c.supervisor = newSupervisor(nil, c.components, nil)
+	ctx := c.supervisor.start(context.Background())
+	defer c.supervisor.shutdown(context.Background())
+
+	if err := c.doStartup(ctx); err == nil {
+		t.Fatal("expected startup to fail")
+	}
+	status := c.ComponentStates()[0]
+	if status.State != modules.StateFailed || status.LastError == nil {
+		t.Errorf("expected the component to be failed, got %+v", status)
+	}
+	if atomic.LoadInt32(&comp.starts) != 0 {
+		t.Error("component should not start after service registration failed")
+	}
+}
+
+// echoRegistrar 注册服务时不设置 WithComponent 的组件
+type echoRegistrar struct {
+	flakyComponent
+	hub *servicehub.ServiceHub
+}
+
+func (e *echoRegistrar) Name() string { return "echo" }
+func (e *echoRegistrar) RegisterService(ctx context.Context) error {
+	return servicehub.Register(e.hub, "echo", func(req string) (string, error) { return req, nil })
+}
+
+func TestContainer_RegisterServiceRecordsComponent(t *testing.T) {
+	hub := servicehub.NewServiceHub()
+	c := &Container{Hub: hub}
+	c.AddV2(&echoRegistrar{hub: hub})
+	c.supervisor = newSupervisor(nil, c.components, nil)
+	if err := c.doRegComponentsService(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := hub.Describe("echo"); info.Component != "echo" {
+		t.Errorf("expected the service to be recorded for component echo, got %q", info.Component)
+	}
+
+	// 注册窗口之外的服务不记录组件
+	servicehub.Register(hub, "later", func(req string) (string, error) { return req, nil })
+	if info, _ := hub.Describe("later"); info.Component != "" {
+		t.Errorf("expected no component outside registration, got %q", info.Component)
+	}
+}